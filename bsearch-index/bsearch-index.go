@@ -0,0 +1,147 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package main
+
+import (
+	"bsearch/index"
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: bsearch-index [flags] [documents.tsv]\n")
+	fmt.Fprintf(os.Stderr, "The first line of the input names the fields of the documents.\n")
+	flag.PrintDefaults()
+	os.Exit(1)
+}
+
+var output = flag.String("o", "db.blob", "Output index blob")
+var idField = flag.String("id", "id", "Field with the document id")
+var orderField = flag.String("order", "", "Field with the sort order of the document, defaults to the id")
+var suborderField = flag.String("suborder", "", "Field with the secondary sort order of the document")
+var attrFields = flag.String("attrs", "", "Comma separated fields that become attributes")
+var wordFields = flag.String("words", "", "Comma separated fields that are indexed as words")
+var shards = flag.Int("shards", 0, "Split the index by document id into this many shards written to <o>.0, <o>.1, ...")
+
+// column returns the column of the named field.
+func column(fields []string, name string) int {
+	for i, f := range fields {
+		if f == name {
+			return i
+		}
+	}
+	log.Fatalf("no field %q in the input", name)
+	return -1
+}
+
+func columns(fields []string, names string) []int {
+	var r []int
+	if names == "" {
+		return r
+	}
+	for _, n := range strings.Split(names, ",") {
+		r = append(r, column(fields, n))
+	}
+	return r
+}
+
+func number(s, name string, line int) uint32 {
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		log.Fatalf("line %d: bad %s %q: %v", line, name, s, err)
+	}
+	return uint32(n)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	var in io.Reader = os.Stdin
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	default:
+		usage()
+	}
+
+	sc := bufio.NewScanner(in)
+	sc.Buffer(nil, 16*1024*1024)
+	if !sc.Scan() {
+		log.Fatal("no header line in the input")
+	}
+	fields := strings.Split(sc.Text(), "\t")
+
+	idcol := column(fields, *idField)
+	ordercol := idcol
+	if *orderField != "" {
+		ordercol = column(fields, *orderField)
+	}
+	subcol := -1
+	if *suborderField != "" {
+		subcol = column(fields, *suborderField)
+	}
+	attrcols := columns(fields, *attrFields)
+	wordcols := columns(fields, *wordFields)
+
+	nw := *shards
+	if nw < 1 {
+		nw = 1
+	}
+	ws := make([]*index.Writer, nw)
+	for i := range ws {
+		ws[i] = index.NewWriter(fields)
+	}
+	for line := 2; sc.Scan(); line++ {
+		data := strings.Split(sc.Text(), "\t")
+		if len(data) != len(fields) {
+			log.Fatalf("line %d: %d fields, expected %d", line, len(data), len(fields))
+		}
+		var doc index.IbDoc
+		doc.Id = number(data[idcol], "id", line)
+		doc.Order = number(data[ordercol], "order", line)
+		if subcol != -1 {
+			doc.Suborder = number(data[subcol], "suborder", line)
+		}
+		var attrs, wds []string
+		for _, c := range attrcols {
+			if data[c] != "" {
+				attrs = append(attrs, fields[c]+":"+data[c])
+			}
+		}
+		for _, c := range wordcols {
+			if len(wds) > 0 {
+				wds = append(wds, make([]string, index.FieldGap)...)
+			}
+			wds = append(wds, index.SplitWords(data[c])...)
+		}
+		ws[doc.Id%uint32(nw)].AddDocument(doc, data, attrs, wds)
+	}
+	if err := sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+	if *shards < 1 {
+		if err := ws[0].WriteFile(*output); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	for i, w := range ws {
+		if err := w.WriteFile(fmt.Sprintf("%v.%d", *output, i)); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package main
+
+import (
+	"bsearch/index"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: bsearch-inspect [flags] <db.blob> <command> [args]\n")
+	fmt.Fprintf(os.Stderr, "commands:\n")
+	fmt.Fprintf(os.Stderr, "  header      header fields and counts\n")
+	fmt.Fprintf(os.Stderr, "  attrs       attributes and the lengths of their postings\n")
+	fmt.Fprintf(os.Stderr, "  meta        the meta data\n")
+	fmt.Fprintf(os.Stderr, "  doc <id>    the document id\n")
+	fmt.Fprintf(os.Stderr, "  top [n]     the n (default 10) largest attribute and word postings\n")
+	fmt.Fprintf(os.Stderr, "  verify [n]  check the index, stop after n (default 100) problems\n")
+	flag.PrintDefaults()
+	os.Exit(1)
+}
+
+var mapped = flag.Bool("mapped", false, "Open the index with OpenMapped, faster for large indexes")
+var legacy = flag.String("legacy", "", "Comma separated header versions of platform 2.3-2.5 blobs to accept, default all")
+
+func header(in *index.Index) {
+	st := in.Stat()
+	fmt.Printf("format: %v\n", st.Format)
+	fmt.Printf("magic: %#x\n", st.Magic)
+	fmt.Printf("version: %v\n", st.Version)
+	fmt.Printf("size: %v\n", st.Size)
+	fmt.Printf("documents: %v\n", st.Documents)
+	fmt.Printf("attributes: %v\n", st.Attrs)
+	fmt.Printf("words: %v\n", st.Words)
+	fmt.Printf("total word length: %v\n", st.TotalWordLen)
+	fmt.Printf("meta size: %v\n", st.MetaSize)
+	fmt.Printf("fields: %v", in.Header())
+}
+
+func attrs(in *index.Index) {
+	in.ForeachAttr(func(key string, docs []index.IbDoc) {
+		fmt.Printf("%v\t%v\n", key, len(docs))
+	})
+}
+
+// meta prints the meta data as bconf keys and values.
+func meta(in *index.Index) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(in.RawMeta(), &m); err != nil {
+		log.Fatalf("meta: %v", err)
+	}
+	var lines []string
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, sv := range v {
+				if prefix != "" {
+					k = prefix + "." + k
+				}
+				walk(k, sv)
+			}
+		default:
+			lines = append(lines, fmt.Sprintf("%v=%v", prefix, v))
+		}
+	}
+	walk("", m)
+	sort.Strings(lines)
+	fmt.Println(strings.Join(lines, "\n"))
+}
+
+func doc(in *index.Index, id string) {
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		log.Fatalf("bad document id %q", id)
+	}
+	d, exists := in.Doc(uint32(n))
+	if !exists {
+		log.Fatalf("no document %v", n)
+	}
+	fields := strings.Split(strings.TrimSuffix(in.Header(), "\n"), "\t")
+	for i, v := range strings.Split(string(d), "\t") {
+		name := fmt.Sprint(i)
+		if i < len(fields) {
+			name = fields[i]
+		}
+		fmt.Printf("%v: %v\n", name, v)
+	}
+}
+
+type posting struct {
+	kind, key string
+	n         int
+}
+
+func top(in *index.Index, n int) {
+	var p []posting
+	in.ForeachAttr(func(key string, docs []index.IbDoc) {
+		p = append(p, posting{"attr", key, len(docs)})
+	})
+	in.ForeachWord(func(w string, word index.Word) {
+		p = append(p, posting{"word", w, len(word.Docs)})
+	})
+	sort.SliceStable(p, func(i, j int) bool { return p[i].n > p[j].n })
+	if n < len(p) {
+		p = p[:n]
+	}
+	for _, e := range p {
+		fmt.Printf("%v\t%v\t%v\n", e.n, e.kind, e.key)
+	}
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 2 {
+		usage()
+	}
+
+	opt := index.Options{Mapped: *mapped}
+	if *legacy != "" {
+		for _, v := range strings.Split(*legacy, ",") {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				log.Fatalf("bad -legacy %q", *legacy)
+			}
+			opt.LegacyVersions = append(opt.LegacyVersions, n)
+		}
+	}
+
+	in, err := index.OpenWith(flag.Arg(0), opt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer in.Close()
+
+	args := flag.Args()[2:]
+	switch flag.Arg(1) {
+	case "header":
+		header(in)
+	case "attrs":
+		attrs(in)
+	case "meta":
+		meta(in)
+	case "doc":
+		if len(args) != 1 {
+			usage()
+		}
+		doc(in, args[0])
+	case "verify":
+		n := 100
+		if len(args) > 0 {
+			n, err = strconv.Atoi(args[0])
+			if err != nil {
+				usage()
+			}
+		}
+		bad := false
+		for _, e := range in.Verify(n) {
+			fmt.Println(e)
+			if ve, ok := e.(*index.VerifyError); !ok || !ve.Warning {
+				bad = true
+			}
+		}
+		if bad {
+			in.Close()
+			os.Exit(1)
+		}
+		fmt.Println("ok")
+	case "top":
+		n := 10
+		if len(args) > 0 {
+			n, err = strconv.Atoi(args[0])
+			if err != nil {
+				usage()
+			}
+		}
+		top(in, n)
+	default:
+		usage()
+	}
+}
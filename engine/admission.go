@@ -0,0 +1,70 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"context"
+	"errors"
+	"runtime"
+)
+
+/*
+ * Admission limits how many queries run at the same time on all ports
+ * together. Queries that can't run wait in a queue until they can or
+ * their timeout runs out. When the queue is full queries are rejected
+ * right away with info:error:overloaded or http status 503.
+ *
+ *   max_queries=16    queries running, default twice the number of cpus
+ *   max_queued=64     queries waiting, default four times max_queries
+ *
+ * The time spent waiting is the "queue" timer of the query.
+ */
+
+var ErrOverloaded = errors.New("overloaded")
+
+type Admission struct {
+	running chan struct{}
+	queued  chan struct{}
+}
+
+func NewAdmission(running, queued int) *Admission {
+	return &Admission{running: make(chan struct{}, running), queued: make(chan struct{}, queued)}
+}
+
+// AdmissionFromConf returns the Admission configured for the engine.
+func (s EngineState) AdmissionFromConf() *Admission {
+	running := s.confInt(2*runtime.NumCPU(), "max_queries")
+	return NewAdmission(running, s.confInt(4*running, "max_queued"))
+}
+
+// enter waits until the query can run. leave must be called when it's
+// done unless enter returned an error.
+func (a *Admission) enter(ctx context.Context) error {
+	if a == nil {
+		return nil
+	}
+	select {
+	case a.running <- struct{}{}:
+		return nil
+	default:
+	}
+	select {
+	case a.queued <- struct{}{}:
+	default:
+		return ErrOverloaded
+	}
+	defer func() { <-a.queued }()
+	select {
+	case a.running <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ErrOverloaded
+	}
+}
+
+func (a *Admission) leave() {
+	if a != nil {
+		<-a.running
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"github.com/art4711/timers"
+)
+
+func TestAdmission(t *testing.T) {
+	a := NewAdmission(1, 1)
+	if err := a.enter(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Waits in the queue until the running query leaves.
+	queued := make(chan error)
+	go func() {
+		queued <- a.enter(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// Queue full.
+	if err := a.enter(context.Background()); err != ErrOverloaded {
+		t.Errorf("enter with full queue: %v", err)
+	}
+	a.leave()
+	if err := <-queued; err != nil {
+		t.Errorf("queued enter: %v", err)
+	}
+
+	// Timeout while queued.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := a.enter(ctx); err != ErrOverloaded {
+		t.Errorf("enter after timeout: %v", err)
+	}
+	a.leave()
+}
+
+func TestOverloaded(t *testing.T) {
+	s := EngineState{Index: testIndex(t, 1, 2, 3), Timer: timers.NewMemStats(), Admit: NewAdmission(1, 0)}
+	s.Admit.enter(context.Background())
+
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	qt := s.Timer.Start("query")
+	s.classicQuery(w, "lim:1 category:1000", "search", qt)
+	qt.Stop()
+	w.Flush()
+	if r := b.String(); r != "info:error:overloaded\n" {
+		t.Errorf("tcp reply: %q", r)
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/x?q=(attr+%22category:1000%22)", nil)
+	s.HandleHTTPQuery(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("http status %v", rec.Code)
+	}
+
+	s.Admit.leave()
+	rec = httptest.NewRecorder()
+	s.HandleHTTPQuery(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("http status %v after leave", rec.Code)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"bsearch/ops"
+	"bsearch/parser/opers"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"github.com/art4711/bconf"
+	"github.com/art4711/timers"
+)
+
+/*
+ * A broker sends queries to other bsearch engines and merges their
+ * results like the shards of an index:
+ *
+ *   broker.all.backend.0=http://host1:4714
+ *   broker.all.backend.1=http://host2:4714
+ *   broker.all.index=ads
+ *   broker.all.timeout_ms=500
+ *
+ * index is the index queried on the backends, without it the default
+ * index is used. Brokers are picked by name just like indexes.
+ *
+ * Backends are queried on their http_search port, or on their search
+ * port if the backend is tcp://host:port, see broker_tcp.go.
+ *
+ * Backends that fail, reply with an error or don't reply in time are
+ * left out of the result and info:partial is set. The backends get what is left of the
+ * query timeout as timeout_ms, info:timeout is set if any of them
+ * timed out.
+ */
+
+const defaultBrokerTimeout = 1000 * time.Millisecond
+
+type Broker struct {
+	backends []string
+	index    string
+	client   *http.Client
+}
+
+// NewBroker creates a broker for the backends, the base urls of the
+// http_search ports of the engines or tcp://host:port of their search
+// ports.
+func NewBroker(backends []string, index string, timeout time.Duration) *Broker {
+	return &Broker{backends: backends, index: index, client: &http.Client{Timeout: timeout}}
+}
+
+func newBrokerFromConf(conf bconf.Bconf) (*Broker, error) {
+	var backends []string
+	for i := 0; ; i++ {
+		b := conf.GetString("backend", fmt.Sprint(i))
+		if b == "" {
+			break
+		}
+		backends = append(backends, b)
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("no backend.0")
+	}
+	timeout := defaultBrokerTimeout
+	if t := conf.GetString("timeout_ms"); t != "" {
+		ms, err := strconv.Atoi(t)
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("bad timeout_ms %q", t)
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+	return NewBroker(backends, conf.GetString("index"), timeout), nil
+}
+
+// backendReply is the part of the http reply of a backend the broker uses.
+type backendReply struct {
+	Info     map[string]interface{}       `json:"info"`
+	Docs     map[string]map[string]string `json:"docs"`
+	Order    []string                     `json:"order"`
+	SortKeys [][2]uint32                  `json:"sort_keys"`
+	Fields   []string                     `json:"fields"`
+}
+
+// timeoutMs returns what is left of the query timeout in ctx in
+// milliseconds.
+func timeoutMs(ctx context.Context) (int64, bool) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	ms := time.Until(d).Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return ms, true
+}
+
+func (b *Broker) fetch(ctx context.Context, backend, q string) (*backendReply, error) {
+	if strings.HasPrefix(backend, tcpScheme) {
+		return b.fetchTCP(ctx, backend[len(tcpScheme):], q)
+	}
+	v := url.Values{"q": {q}}
+	if b.index != "" {
+		v.Set("index", b.index)
+	}
+	if ms, ok := timeoutMs(ctx); ok {
+		v.Set("timeout_ms", fmt.Sprint(ms))
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", backend+"/x?"+v.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v", resp.Status)
+	}
+	var r backendReply
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// check returns an error if the documents of the reply can't be merged.
+func (r *backendReply) check() error {
+	if len(r.SortKeys) != len(r.Order) {
+		return fmt.Errorf("%d sort keys for %d documents", len(r.SortKeys), len(r.Order))
+	}
+	for _, id := range r.Order {
+		if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+			return fmt.Errorf("bad document id %q", id)
+		}
+	}
+	return nil
+}
+
+func (b *Broker) query(ctx context.Context, o *opers.Op, hc ops.HeaderCollector, et *timers.Event) ([]hit, []string, func(), []error) {
+	release := func() {}
+
+	sq, offset, limit, err := o.Sharded()
+	if err != nil {
+		return nil, nil, release, []error{err}
+	}
+	q := sq.String()
+
+	t := et.Start("backends")
+	replies := make([]*backendReply, len(b.backends))
+	var wg sync.WaitGroup
+	for i := range b.backends {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := b.fetch(ctx, b.backends[i], q)
+			if err != nil {
+				log.Printf("broker: %v: %v", b.backends[i], err)
+				return
+			}
+			replies[i] = r
+		}(i)
+	}
+	wg.Wait()
+
+	t = t.Handover("merge")
+	defer t.Stop()
+	var hits []hit
+	var fields []string
+	partial := false
+	timeout := false
+	sh := make(sumHeaders)
+	for i, r := range replies {
+		if r == nil {
+			partial = true
+			continue
+		}
+		if e, exists := r.Info["error"]; exists {
+			log.Printf("broker: %v: %v", b.backends[i], e)
+			partial = true
+			continue
+		}
+		if fields == nil {
+			fields = r.Fields
+		}
+		for j, id := range r.Order {
+			n, _ := strconv.ParseUint(id, 10, 32) // checked by fetch
+			d := &index.IbDoc{Id: uint32(n), Order: r.SortKeys[j][0], Suborder: r.SortKeys[j][1]}
+			hits = append(hits, hit{doc: d, fields: r.Docs[id]})
+		}
+		for k, v := range r.Info {
+			switch v := v.(type) {
+			case string:
+				switch k {
+				case "partial":
+					partial = true
+				case "timeout":
+					timeout = true
+				default:
+					sh.Add(k, v)
+				}
+			case map[string]interface{}:
+				for sk, sv := range v {
+					sh.AddSub(k, sk, fmt.Sprint(sv))
+				}
+			}
+		}
+	}
+	hits = merge(hits, offset, limit)
+	sh.replay(hc)
+	if partial {
+		hc.Add("partial", "1")
+	}
+	if timeout || (partial && ctx.Err() == context.DeadlineExceeded) {
+		hc.Add("timeout", "1")
+	}
+	return hits, fields, release, nil
+}
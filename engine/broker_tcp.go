@@ -0,0 +1,126 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ * tcp://host:port backends get the structured query on their search
+ * port as one "#<length>\n" frame:
+ *
+ *   @<index> timeout=<ms> sort_keys=1 (attr "category:1000")
+ *
+ * sort_keys=1 makes the backend put the id, Order and Suborder in front
+ * of every document line, the broker merges the results with them.
+ */
+
+const tcpScheme = "tcp://"
+
+func (b *Broker) fetchTCP(ctx context.Context, addr, q string) (*backendReply, error) {
+	pre := ""
+	if b.index != "" {
+		pre += "@" + b.index + " "
+	}
+	if ms, ok := timeoutMs(ctx); ok {
+		pre += fmt.Sprintf("%s%d ", timeoutPrefix, ms)
+	}
+	q = pre + sortKeysPrefix + q
+
+	var deadline time.Time
+	if b.client.Timeout > 0 {
+		deadline = time.Now().Add(b.client.Timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	if _, err := fmt.Fprintf(conn, "#%d\n%s", len(q), q); err != nil {
+		return nil, err
+	}
+	r, err := readTCPReply(bufio.NewReader(conn))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// readTCPReply reads the reply to a sort_keys=1 query, the info
+// headers, the field names and the documents, until the backend closes
+// the connection.
+func readTCPReply(rd *bufio.Reader) (*backendReply, error) {
+	r := &backendReply{Info: make(map[string]interface{}), Docs: make(map[string]map[string]string)}
+	for {
+		line, err := rd.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return r, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case r.Fields == nil && strings.HasPrefix(line, "info:"):
+			kv := strings.SplitN(line[len("info:"):], ":", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("bad header %q", line)
+			}
+			k, v := kv[0], kv[1]
+			if k == "error" {
+				r.Info[k] = v
+				continue
+			}
+			// Sub headers are "info:<key>:<subkey>:<value>".
+			if i := strings.LastIndexByte(v, ':'); i != -1 {
+				sub, _ := r.Info[k].(map[string]interface{})
+				if sub == nil {
+					sub = make(map[string]interface{})
+					r.Info[k] = sub
+				}
+				sub[v[:i]] = v[i+1:]
+				continue
+			}
+			r.Info[k] = v
+		case r.Fields == nil:
+			r.Fields = strings.Split(line, "\t")
+		default:
+			cols := strings.SplitN(line, "\t", 4)
+			if len(cols) != 4 {
+				return nil, fmt.Errorf("bad document %q", line)
+			}
+			order, err1 := strconv.ParseUint(cols[1], 10, 32)
+			suborder, err2 := strconv.ParseUint(cols[2], 10, 32)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("bad sort keys in %q", line)
+			}
+			doc := make(map[string]string)
+			for i, v := range strings.Split(cols[3], "\t") {
+				if i < len(r.Fields) && v != "" {
+					doc[r.Fields[i]] = v
+				}
+			}
+			r.Docs[cols[0]] = doc
+			r.Order = append(r.Order, cols[0])
+			r.SortKeys = append(r.SortKeys, [2]uint32{uint32(order), uint32(suborder)})
+		}
+	}
+}
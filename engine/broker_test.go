@@ -0,0 +1,164 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+	"github.com/art4711/timers"
+)
+
+// testIndex opens an index with the documents ids, all in category 1000.
+func testIndex(t *testing.T, ids ...uint32) *IndexHolder {
+	w := index.NewWriter([]string{"id", "category"})
+	for _, id := range ids {
+		w.AddDocument(index.IbDoc{Id: id, Order: id}, []string{fmt.Sprint(id), "1000"}, []string{"category:1000"}, nil)
+	}
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatal(err)
+	}
+	ih, err := NewIndexHolder(index.Open, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ih.Close)
+	return ih
+}
+
+// backend starts an engine serving http queries on testIndex.
+func backend(t *testing.T, ids ...uint32) *httptest.Server {
+	s := EngineState{Index: testIndex(t, ids...), Timer: timers.NewMemStats()}
+	srv := httptest.NewServer(http.HandlerFunc(s.HandleHTTPQuery))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func brokerQuery(t *testing.T, b *Broker, q string) map[string]interface{} {
+	s := EngineState{Brokers: map[string]*Broker{"all": b}, Timer: timers.NewMemStats()}
+	srv := httptest.NewServer(http.HandlerFunc(s.HandleHTTPQuery))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/x?" + url.Values{"index": {"all"}, "q": {q}}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var r map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestBroker(t *testing.T) {
+	b1 := backend(t, 1, 4, 5)
+	b2 := backend(t, 2, 3, 6)
+	b := NewBroker([]string{b1.URL, b2.URL}, "", time.Second)
+
+	r := brokerQuery(t, b, `(offset [ 1 ] (limit [ 3 ] (count_all "n" (attr "category:1000"))))`)
+	if o := fmt.Sprint(r["order"]); o != "[5 4 3]" {
+		t.Errorf("wrong order: %v", o)
+	}
+	info := r["info"].(map[string]interface{})
+	if info["n"] != "6" {
+		t.Errorf("wrong count: %v", info["n"])
+	}
+	if _, exists := info["partial"]; exists {
+		t.Errorf("unexpected partial result")
+	}
+}
+
+func TestBrokerTimeout(t *testing.T) {
+	b1 := backend(t, 1, 4, 5)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(time.Second)
+	}))
+	defer slow.Close()
+	b := NewBroker([]string{b1.URL, slow.URL}, "", 100*time.Millisecond)
+
+	r := brokerQuery(t, b, `(attr "category:1000")`)
+	if o := fmt.Sprint(r["order"]); o != "[5 4 1]" {
+		t.Errorf("wrong order: %v", o)
+	}
+	if info := r["info"].(map[string]interface{}); info["partial"] != "1" {
+		t.Errorf("not partial: %v", info)
+	}
+}
+
+func TestBrokerBackendError(t *testing.T) {
+	b1 := backend(t, 1, 4, 5)
+	overloaded := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"info":{"error":"overloaded"}}`))
+	}))
+	defer overloaded.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"info":{"error":"parse error"}}`))
+	}))
+	defer broken.Close()
+	b := NewBroker([]string{b1.URL, overloaded.URL, broken.URL}, "", time.Second)
+
+	r := brokerQuery(t, b, `(count_all "n" (attr "category:1000"))`)
+	if o := fmt.Sprint(r["order"]); o != "[5 4 1]" {
+		t.Errorf("wrong order: %v", o)
+	}
+	info := r["info"].(map[string]interface{})
+	if info["partial"] != "1" || info["n"] != "3" {
+		t.Errorf("wrong info: %v", info)
+	}
+	if _, exists := info["error"]; exists {
+		t.Errorf("backend error fails the query: %v", info)
+	}
+}
+
+// tcpBackend starts an engine serving queries on testIndex on a tcp
+// port like the search port.
+func tcpBackend(t *testing.T, ids ...uint32) string {
+	s := EngineState{Index: testIndex(t, ids...), Timer: timers.NewMemStats()}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return "tcp://" + ln.Addr().String()
+}
+
+func TestBrokerTCP(t *testing.T) {
+	b1 := tcpBackend(t, 1, 4, 5)
+	b2 := backend(t, 2, 3, 6)
+	b := NewBroker([]string{b1, b2.URL}, "", time.Second)
+
+	r := brokerQuery(t, b, `(offset [ 1 ] (limit [ 3 ] (count_by "c" [ category ] (count_all "n" (attr "category:1000")))))`)
+	if o := fmt.Sprint(r["order"]); o != "[5 4 3]" {
+		t.Errorf("wrong order: %v", o)
+	}
+	docs := r["docs"].(map[string]interface{})
+	if d := fmt.Sprint(docs["4"]); d != "map[category:1000 id:4]" {
+		t.Errorf("wrong document: %v", d)
+	}
+	info := r["info"].(map[string]interface{})
+	if info["n"] != "6" || fmt.Sprint(info["c"]) != "map[1000:6]" {
+		t.Errorf("wrong counters: %v", info)
+	}
+	if _, exists := info["partial"]; exists {
+		t.Errorf("unexpected partial result: %v", info)
+	}
+}
@@ -4,15 +4,58 @@
 package engine
 
 import (
+	"bytes"
+	"fmt"
+	"log"
 	"net/http"
+	"net/http/httptest"
+	"os"
 	"github.com/art4711/timers"
 )
 
+// Shutdown stops the engine without dropping running queries, see
+// Lifecycle. If timers_file is set in the config the timers are
+// written to it as JSON.
+func (s EngineState) Shutdown() {
+	timeout := s.confTimeout(defaultShutdownTimeout, "shutdown_timeout_ms")
+	if !s.Life.Shutdown(timeout) {
+		log.Printf("shutdown: queries still running after %v", timeout)
+	}
+	if name := s.Conf.GetString("timers_file"); name != "" {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/timers", nil)
+		s.Timer.JSONHandler(rec, req)
+		if err := os.WriteFile(name, bytes.TrimSpace(rec.Body.Bytes()), 0644); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+	}
+}
+
+// Stop asks the engine to stop by sending "stop" on cchan. The engine
+// stops on the first one, the ones after it are dropped instead of
+// blocking.
+func Stop(cchan chan string) {
+	select {
+	case cchan <- "stop":
+	default:
+	}
+}
+
 func (s EngineState) ControlHTTP(cchan chan string) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/stop", func(w http.ResponseWriter, req *http.Request) {
-		cchan <- "stop"
+		Stop(cchan)
 	})
+	mux.HandleFunc("/reload", func(w http.ResponseWriter, req *http.Request) {
+		t := s.Timer.Start("reload")
+		defer t.Stop()
+		if err := s.Reload(req.FormValue("index")); err != nil {
+			http.Error(w, fmt.Sprintf("reload: %v", err), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "ok\n")
+	})
+	mux.HandleFunc("/update", s.HandleUpdate)
 	mux.HandleFunc("/timers", func (w http.ResponseWriter, req *http.Request) {
 		s.Timer.JSONHandler(w, req)
 	})
@@ -25,6 +68,8 @@ func (s EngineState) ControlHTTP(cchan chan string) {
 		Addr: addr,
 		Handler: mux,
 	}
-	hs.ListenAndServe()
+	if s.Life.addServer(hs) {
+		hs.ListenAndServe()
+	}
 }
 
@@ -0,0 +1,89 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"github.com/art4711/timers"
+)
+
+// countIndex has three documents in category 1000 and one in 2000.
+func countIndex(t *testing.T) *IndexHolder {
+	w := index.NewWriter([]string{"id", "category"})
+	for i, c := range []string{"1000", "2000", "1000", "1000"} {
+		id := uint32(i + 1)
+		w.AddDocument(index.IbDoc{Id: id, Order: id}, []string{fmt.Sprint(id), c}, []string{"a:a"}, nil)
+	}
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatal(err)
+	}
+	ih, err := NewIndexHolder(index.Open, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ih.Close)
+	return ih
+}
+
+func TestCountByTCP(t *testing.T) {
+	s := EngineState{Index: countIndex(t), Timer: timers.NewMemStats()}
+	client, server := net.Pipe()
+	go s.handle(server)
+	defer client.Close()
+
+	go client.Write([]byte("lim:1 count_by(cat, category) a:a\n"))
+	b, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info []string
+	for _, l := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(l, "info:") {
+			info = append(info, l)
+		}
+	}
+	sort.Strings(info)
+	if r := strings.Join(info, " "); r != "info:cat:1000:3 info:cat:2000:1" {
+		t.Errorf("headers: %q", r)
+	}
+}
+
+func TestCountByHTTP(t *testing.T) {
+	s := EngineState{Index: countIndex(t), Timer: timers.NewMemStats()}
+	srv := httptest.NewServer(http.HandlerFunc(s.HandleHTTPQuery))
+	defer srv.Close()
+
+	q := `(limit [ 1 ] (count_by "cat" [ category ] (attr "a:a")))`
+	resp, err := http.Get(srv.URL + "/x?" + url.Values{"q": {q}}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var r struct {
+		Info map[string]json.RawMessage
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	// The counts are an object in info, not "cat:1000" keys.
+	var cat map[string]string
+	if err := json.Unmarshal(r.Info["cat"], &cat); err != nil {
+		t.Fatalf("info.cat: %s: %v", r.Info["cat"], err)
+	}
+	if len(cat) != 2 || cat["1000"] != "3" || cat["2000"] != "1" {
+		t.Errorf("info.cat: %v", cat)
+	}
+}
@@ -6,10 +6,14 @@ package engine
 import (
 	"bsearch/index"
 	"bsearch/parser"
+	"bsearch/parser/opers"
 	"bsearch/ops"
+	"context"
 	"fmt"
 	"log"
 	"net"
+	"strings"
+	"time"
 	"github.com/art4711/bconf"
 	"github.com/art4711/timers"
 	"bufio"
@@ -21,28 +25,49 @@ func (h headers) Add(k, v string) {
 	h[k] = v
 }
 
+func (h headers) AddSub(k, sk, v string) {
+	h[k+":"+sk] = v
+}
+
 type EngineState struct {
 	Conf bconf.Bconf
-	Index *index.Index
+	Index *IndexHolder
+	Indexes map[string]*IndexHolder
+	Brokers map[string]*Broker
 	Timer *timers.Timer
+	Life *Lifecycle
+	Admit *Admission
 	
 }
 
 func (s EngineState) Listener() {
-	listenport := s.Conf.GetString("port", "search")
+	s.serve(s.Conf.GetString("port", "search"), s.handle)
+}
 
-	ln, err := net.Listen("tcp", ":" + listenport)
-	if err != nil {
-		log.Fatal("listen: %v\n", err)
+// splitIndexName splits off the "@name " that picks the index in
+// front of a query.
+func splitIndexName(q string) (string, string) {
+	if !strings.HasPrefix(q, "@") {
+		return "", q
+	}
+	i := strings.IndexByte(q, ' ')
+	if i == -1 {
+		return q[1:], ""
 	}
+	return q[1:i], q[i+1:]
+}
 
-	for {
-		conn, err := ln.Accept()
-		if err != nil {
-			log.Fatal("accept %v\n", err)
-		}
-		go s.handle(conn)
+// sortKeysPrefix after "timeout=<ms> " in front of a query puts the
+// id, Order and Suborder of the documents in front of their fields.
+// Brokers merge the results of tcp backends with them.
+const sortKeysPrefix = "sort_keys=1 "
+
+// splitSortKeys splits off the sortKeysPrefix in front of a query.
+func splitSortKeys(q string) (bool, string) {
+	if !strings.HasPrefix(q, sortKeysPrefix) {
+		return false, q
 	}
+	return true, q[len(sortKeysPrefix):]
 }
 
 func (s EngineState) handle(conn net.Conn) {
@@ -51,61 +76,111 @@ func (s EngineState) handle(conn net.Conn) {
 	defer qt.Stop()
 
 	et := qt.Start("read")
-	b := [2048]byte{}
-	n, err := conn.Read(b[:])
-	if err != nil {
-		log.Fatal("conn.Read %v\n", err)
-	}
-	bq := b[:n]
+	deadline := time.Now().Add(s.confTimeout(defaultReadTimeout, "timeout", "read_ms"))
+	conn.SetReadDeadline(deadline)
+	q, err := readQuery(conn, bufio.NewReader(conn), s.confInt(defaultMaxQuerySize, "max_query_size"), deadline)
+	et.Stop()
 
+	conn.SetWriteDeadline(time.Now().Add(s.confTimeout(defaultWriteTimeout, "timeout", "write_ms")))
 	writer := bufio.NewWriter(conn)
 	defer writer.Flush()
 
+	if err != nil {
+		writeError(writer, "read", err)
+		return
+	}
+	s.classicQuery(writer, q, "search", qt)
+}
+
+// classicQuery runs a classic query that came in on port and writes
+// the result to writer. Queries that start with "(", which no classic
+// query does, are structured queries.
+func (s EngineState) classicQuery(writer *bufio.Writer, query string, port string, qt *timers.Event) {
+	et := qt.Start("index")
+	name, bq := splitIndexName(query)
+	src, err := s.source(name)
+	if err != nil {
+		writeError(writer, "index", err)
+		et.Stop()
+		return
+	}
+	asked, bq := splitTimeout(bq)
+	timeout, err := s.queryTimeout(port, asked)
+	if err != nil {
+		writeError(writer, "timeout", err)
+		et.Stop()
+		return
+	}
+	sortKeys, bq := splitSortKeys(bq)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	et = et.Handover("queue")
+	if err := s.Admit.enter(ctx); err != nil {
+		fmt.Fprintf(writer, "info:error:%v\n", err)
+		et.Stop()
+		return
+	}
 	et = et.Handover("parse")
-	q, errsl := parser.Classic(s.Index, string(bq))
+	var o *opers.Op
+	var errsl []error
+	if strings.HasPrefix(bq, "(") {
+		o, errsl = parser.ParseStructured(bq, et)
+	} else {
+		o, errsl = parser.ParseClassic(bq)
+	}
+	h := make(headers)
+	var hits []hit
+	var fields []string
+	if errsl == nil {
+		var release func()
+		et = et.Handover("query")
+		hits, fields, release, errsl = src.query(ctx, o, h, et)
+		defer release()
+	}
+	s.Admit.leave()
 	if errsl != nil {
 		for k, v := range errsl {
-			fmt.Fprintf(writer, "info:error:%v:%v\n", k, v)
+			writeError(writer, k, v)
 		}
 		et.Stop()
 		return
 	}
 
-	et = et.Handover("performQuery")
-
-	docarr := performQuery(q, et)
-
-	et = et.Handover("ProcessHeaders")
-	h := make(headers)
-	q.ProcessHeaders(h)
-
 	et = et.Handover("writeHeaders")
 	for k, v := range h {
 		fmt.Fprintf(writer, "info:%v:%v\n", k, v)
 	}
-	writer.WriteString(s.Index.Header())
+	writer.WriteString(strings.Join(fields, "\t") + "\n")
 	et = et.Handover("writeDocs")
-	for o, d := range docarr {
+	for o, ht := range hits {
+		d := ht.doc
 		if d == nil {
-			log.Fatalf("nil in docarr at %v", o)
+			log.Printf("nil in docarr at %v", o)
+			continue
 		}
-		doc, exists := s.Index.Docs[d.Id]
+		doc, exists := ht.data(fields)
 		if !exists {
 			log.Printf("Doc %v does not exist", d.Id)
 		}
+		if sortKeys {
+			fmt.Fprintf(writer, "%d\t%d\t%d\t", d.Id, d.Order, d.Suborder)
+		}
 		writer.Write(doc)
 		writer.WriteString("\n")
 	}
 	et.Stop()
 }
 
-func performQuery(q ops.QueryOp, et *timers.Event) []*index.IbDoc {
+// performQuery returns the documents of q. If dl expires the documents
+// found until then are returned.
+func performQuery(q ops.QueryOp, dl *ops.Deadline, et *timers.Event) []*index.IbDoc {
 	docarr := make([]*index.IbDoc, 0)
 
 	search := index.NullDoc()
 	for {
 		d := q.NextDoc(search)
-		if d == nil {
+		if d == nil || dl.Expired() {
 			break
 		}
 		docarr = append(docarr, d)
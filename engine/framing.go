@@ -0,0 +1,176 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ * Queries on the tcp ports are either one line or "#<length>\n"
+ * followed by <length> bytes of query. On the search port the query
+ * may also end with the end of the connection instead of a newline.
+ * A client that closes its end gets the result right away. Old clients
+ * send the query without a newline and wait for the result without
+ * closing their end, a query on the search port that isn't followed by
+ * anything for lonePause while the connection stays open is taken as
+ * it is.
+ *
+ * The limits of the connections are configured with:
+ *
+ *   max_query_size=65536    bytes in a query
+ *   timeout.read_ms=10000   to read a query on the search port
+ *   timeout.idle_ms=300000  to wait for the next query on the keepalive port
+ *   timeout.write_ms=10000  to write a result
+ */
+
+var ErrFrame = errors.New("bad query length")
+var ErrQueryTooLarge = errors.New("query too large")
+
+const (
+	defaultMaxQuerySize = 64 * 1024
+	defaultReadTimeout  = 10 * time.Second
+	defaultIdleTimeout  = 5 * time.Minute
+	defaultWriteTimeout = 10 * time.Second
+)
+
+const lonePause = 50 * time.Millisecond
+
+func (s EngineState) confInt(def int, key ...string) int {
+	v := s.Conf.GetString(key...)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Printf("bad %v=%q, using %v", strings.Join(key, "."), v, def)
+		return def
+	}
+	return n
+}
+
+func (s EngineState) confTimeout(def time.Duration, key ...string) time.Duration {
+	return time.Duration(s.confInt(int(def/time.Millisecond), key...)) * time.Millisecond
+}
+
+// readLine reads a line of at most max bytes without the newline.
+func readLine(r *bufio.Reader, max int) (string, error) {
+	var b []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		b = append(b, chunk...)
+		if len(strings.TrimRight(string(b), "\r\n")) > max {
+			return "", ErrQueryTooLarge
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && len(b) > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		break
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// readFrame reads one query of at most max bytes.
+func readFrame(r *bufio.Reader, max int) (string, error) {
+	line, err := readLine(r, max)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(line, "#") {
+		return line, nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 {
+		return "", ErrFrame
+	}
+	if n > max {
+		return "", ErrQueryTooLarge
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readQuery reads one query on the search port from r, that reads
+// from conn. deadline is the read deadline of conn.
+func readQuery(conn net.Conn, r *bufio.Reader, max int, deadline time.Time) (string, error) {
+	if _, err := r.Peek(1); err != nil {
+		return readFrame(r, max)
+	}
+	for {
+		b, _ := r.Peek(r.Buffered())
+		if bytes.HasPrefix(b, []byte("#")) || bytes.IndexByte(b, '\n') != -1 || len(b) > max || len(b) >= r.Size() {
+			break
+		}
+		conn.SetReadDeadline(time.Now().Add(lonePause))
+		_, err := r.Peek(len(b) + 1)
+		conn.SetReadDeadline(deadline)
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			r.Discard(len(b))
+			return strings.TrimRight(string(b), "\r"), nil
+		}
+		if err != nil {
+			// EOF, the query ends with the connection and
+			// readFrame takes what's buffered without waiting.
+			break
+		}
+	}
+	return readFrame(r, max)
+}
+
+// writeError writes an error as an info:error header. The header is
+// always one line.
+func writeError(writer *bufio.Writer, key interface{}, err interface{}) {
+	msg := strings.Replace(fmt.Sprint(err), "\n", " ", -1)
+	fmt.Fprintf(writer, "info:error:%v:%v\n", key, msg)
+}
+
+// serve accepts connections on port and hands them to handle until
+// the engine shuts down.
+func (s EngineState) serve(port string, handle func(net.Conn)) {
+	ln, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatalf("listen: %v\n", err)
+	}
+	if !s.Life.addListener(ln) {
+		return
+	}
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.Life.Closing() {
+				return
+			}
+			log.Printf("accept %v\n", err)
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		if !s.Life.start(conn) {
+			conn.Close()
+			return
+		}
+		go func() {
+			defer s.Life.done(conn)
+			handle(conn)
+		}()
+	}
+}
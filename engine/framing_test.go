@@ -0,0 +1,97 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"github.com/art4711/bconf"
+	"github.com/art4711/timers"
+)
+
+func TestFraming(t *testing.T) {
+	s := EngineState{Conf: bconf.Bconf{"max_query_size": "30"}, Index: testIndex(t, 1, 2, 3), Timer: timers.NewMemStats()}
+
+	query := func(chunks ...string) string {
+		client, server := net.Pipe()
+		go s.handle(server)
+		defer client.Close()
+		go func() {
+			for _, c := range chunks {
+				client.Write([]byte(c))
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+		b, err := io.ReadAll(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if r := query("lim:1 cate", "gory:1000\n"); r != "id\tcategory\n3\t1000\n" {
+		t.Errorf("split query: %q", r)
+	}
+	// Old clients don't end the query with a newline or close their end.
+	if r := query("lim:1 category:1000"); r != "id\tcategory\n3\t1000\n" {
+		t.Errorf("query without newline: %q", r)
+	}
+	if r := query("lim:1 cate", "gory:1000"); r != "id\tcategory\n3\t1000\n" {
+		t.Errorf("split query without newline: %q", r)
+	}
+	if r := query(strings.Repeat("a:a ", 10) + "\n"); r != "info:error:read:query too large\n" {
+		t.Errorf("large query: %q", r)
+	}
+	if r := query("#9999\n"); r != "info:error:read:query too large\n" {
+		t.Errorf("large frame: %q", r)
+	}
+	if r := query("lim:1 (\n"); !strings.HasPrefix(r, "info:error:0:") || strings.Count(r, "\n") != 1 {
+		t.Errorf("parse error: %q", r)
+	}
+}
+
+// A client that closes its end after the query doesn't wait for
+// lonePause.
+func TestFramingCloseWrite(t *testing.T) {
+	s := EngineState{Index: testIndex(t, 1, 2, 3), Timer: timers.NewMemStats()}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+
+	for _, q := range []string{"lim:1 category:1000", "lim:1 cate"} {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		conn.Write([]byte(q))
+		conn.(*net.TCPConn).CloseWrite()
+		b, err := io.ReadAll(conn)
+		took := time.Since(start)
+		conn.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) == 0 {
+			t.Errorf("%q: no result", q)
+		}
+		if took >= lonePause {
+			t.Errorf("%q: took %v", q, took)
+		}
+	}
+}
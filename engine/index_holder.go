@@ -0,0 +1,123 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"sync"
+	"time"
+)
+
+// IndexHolder holds the index queries run on and lets it be replaced
+// while queries are running. Queries get the index with Acquire and
+// the old index is only closed when all queries using it are done.
+// An index can be split into shards, they are always opened and
+// replaced together.
+type IndexHolder struct {
+	mtx    sync.RWMutex
+	reload sync.Mutex
+	cur    *heldIndex
+	names  []string
+	open   func(string) (*index.Index, error)
+}
+
+type heldIndex struct {
+	shards []*index.Index
+	users  sync.WaitGroup
+	closed sync.Once
+}
+
+// NewIndexHolder opens the shards of an index with open. The same
+// names are opened again on Reload.
+func NewIndexHolder(open func(string) (*index.Index, error), names ...string) (*IndexHolder, error) {
+	ih := &IndexHolder{names: names, open: open}
+	h, err := ih.open_shards()
+	if err != nil {
+		return nil, err
+	}
+	ih.cur = h
+	return ih, nil
+}
+
+func (ih *IndexHolder) open_shards() (*heldIndex, error) {
+	h := &heldIndex{}
+	for _, name := range ih.names {
+		in, err := ih.open(name)
+		if err != nil {
+			h.close()
+			return nil, err
+		}
+		h.shards = append(h.shards, in)
+	}
+	return h, nil
+}
+
+// Acquire returns the shards of the current index and a function that
+// must be called when the caller is done with them.
+func (ih *IndexHolder) Acquire() ([]*index.Index, func()) {
+	ih.mtx.RLock()
+	h := ih.cur
+	h.users.Add(1)
+	ih.mtx.RUnlock()
+	return h.shards, h.users.Done
+}
+
+// Reload opens the index again and swaps it in for new queries. On
+// errors the current index is kept.
+func (ih *IndexHolder) Reload() error {
+	ih.reload.Lock()
+	defer ih.reload.Unlock()
+
+	h, err := ih.open_shards()
+	if err != nil {
+		return err
+	}
+	ih.mtx.Lock()
+	old := ih.cur
+	ih.cur = h
+	ih.mtx.Unlock()
+
+	go old.close()
+	return nil
+}
+
+// Close closes the current index once nobody is using it.
+func (ih *IndexHolder) Close() {
+	ih.mtx.RLock()
+	h := ih.cur
+	ih.mtx.RUnlock()
+	h.close()
+}
+
+// CloseWithin is Close that waits at most timeout for the queries
+// using the index. It returns false if they didn't finish in time, the
+// index is closed when they do.
+func (ih *IndexHolder) CloseWithin(timeout time.Duration) bool {
+	ih.mtx.RLock()
+	h := ih.cur
+	ih.mtx.RUnlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.close()
+		close(done)
+	}()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+func (h *heldIndex) close() {
+	h.users.Wait()
+	h.closed.Do(func() {
+		for _, in := range h.shards {
+			in.Close()
+		}
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// replaceBlob writes a blob with the document id over name the way a
+// new index is deployed, the open index keeps the old file.
+func replaceBlob(t *testing.T, name string, id uint32) {
+	w := index.NewWriter([]string{"id"})
+	w.AddDocument(index.IbDoc{Id: id, Order: id}, []string{"doc"}, []string{"a:a"}, nil)
+	tmp := name + ".new"
+	if err := w.WriteFile(tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "db.blob")
+	replaceBlob(t, name, 1)
+	ih, err := NewIndexHolder(index.Open, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ih.Close()
+
+	old := ih.cur
+	shards, release := ih.Acquire()
+	drained := make(chan struct{})
+	go func() {
+		old.users.Wait()
+		close(drained)
+	}()
+
+	replaceBlob(t, name, 2)
+	if err := ih.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	nshards, nrelease := ih.Acquire()
+	if _, ok := nshards[0].Doc(2); !ok {
+		t.Error("new queries don't get the new index")
+	}
+	nrelease()
+
+	// The query that had the old index keeps it until it's done.
+	select {
+	case <-drained:
+		t.Fatal("old index released while in use")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if _, ok := shards[0].Doc(1); !ok {
+		t.Error("old index changed under the query")
+	}
+	release()
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("old index not released")
+	}
+}
+
+func TestReloadError(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "db.blob")
+	replaceBlob(t, name, 1)
+	ih, err := NewIndexHolder(index.Open, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ih.Close()
+
+	if err := os.WriteFile(name+".new", []byte("not a blob"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(name+".new", name); err != nil {
+		t.Fatal(err)
+	}
+	if err := ih.Reload(); err == nil {
+		t.Fatal("no error from a broken blob")
+	}
+	shards, release := ih.Acquire()
+	defer release()
+	if _, ok := shards[0].Doc(1); !ok {
+		t.Error("old index not kept")
+	}
+}
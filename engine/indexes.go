@@ -0,0 +1,178 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ * Besides the default index in db_name the config can have named
+ * indexes:
+ *
+ *   index.ads.db_name=/path/to/ads.blob
+ *   index.ads.db_mapped=1
+ *   index.ads.db_shards=4
+ *
+ * Queries without an index name go to the default index. With
+ * db_shards the index is split into the blobs db_name.0 to
+ * db_name.<db_shards - 1>, like bsearch-index -shards writes them.
+ *
+ * Platform 2.3-2.5 blobs are opened whatever the Version in their
+ * header is, unless legacy_versions, a comma separated list, says which
+ * versions to accept. The error from opening a blob with an unlisted
+ * version tells what it is.
+ */
+
+var ErrNoIndexes = errors.New("no db_name, index.<name>.db_name or broker.<name> in the config")
+
+func newIndexHolder(dbname, mapped, shards string, legacy []uint64) (*IndexHolder, error) {
+	opt := index.Options{Mapped: mapped == "1", LegacyVersions: legacy}
+	open := func(name string) (*index.Index, error) {
+		return index.OpenWith(name, opt)
+	}
+	if shards == "" {
+		return NewIndexHolder(open, dbname)
+	}
+	n, err := strconv.Atoi(shards)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad db_shards %q", shards)
+	}
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("%v.%d", dbname, i)
+	}
+	return NewIndexHolder(open, names...)
+}
+
+// OpenIndexes opens all the indexes in the config.
+func (s *EngineState) OpenIndexes() error {
+	var err error
+	var legacy []uint64
+	if lv := s.Conf.GetString("legacy_versions"); lv != "" {
+		for _, v := range strings.Split(lv, ",") {
+			n, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return fmt.Errorf("bad legacy_versions %q", lv)
+			}
+			legacy = append(legacy, n)
+		}
+	}
+	if dbname := s.Conf.GetString("db_name"); dbname != "" {
+		s.Index, err = newIndexHolder(dbname, s.Conf.GetString("db_mapped"), s.Conf.GetString("db_shards"), legacy)
+		if err != nil {
+			return fmt.Errorf("%v: %v", dbname, err)
+		}
+	}
+	s.Indexes = make(map[string]*IndexHolder)
+	for name := range s.Conf.GetNode("index") {
+		dbname := s.Conf.GetString("index", name, "db_name")
+		if dbname == "" {
+			continue
+		}
+		ih, err := newIndexHolder(dbname, s.Conf.GetString("index", name, "db_mapped"), s.Conf.GetString("index", name, "db_shards"), legacy)
+		if err != nil {
+			s.CloseIndexes()
+			return fmt.Errorf("index %v: %v: %v", name, dbname, err)
+		}
+		s.Indexes[name] = ih
+	}
+	s.Brokers = make(map[string]*Broker)
+	for name := range s.Conf.GetNode("broker") {
+		if _, exists := s.Indexes[name]; exists {
+			s.CloseIndexes()
+			return fmt.Errorf("broker %v: there is an index with the same name", name)
+		}
+		b, err := newBrokerFromConf(s.Conf.GetNode("broker", name))
+		if err != nil {
+			s.CloseIndexes()
+			return fmt.Errorf("broker %v: %v", name, err)
+		}
+		s.Brokers[name] = b
+	}
+	if s.Index == nil && len(s.Indexes) == 0 && len(s.Brokers) == 0 {
+		return ErrNoIndexes
+	}
+	return nil
+}
+
+// source returns the index or broker name.
+func (s EngineState) source(name string) (source, error) {
+	if b, exists := s.Brokers[name]; exists {
+		return b, nil
+	}
+	return s.Holder(name)
+}
+
+// IndexNames returns the names of the named indexes, sorted.
+func (s EngineState) IndexNames() []string {
+	r := make([]string, 0, len(s.Indexes))
+	for name := range s.Indexes {
+		r = append(r, name)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// Holder returns the index name, or the default index if name is empty.
+func (s EngineState) Holder(name string) (*IndexHolder, error) {
+	if name == "" {
+		if s.Index == nil {
+			return nil, errors.New("no default index")
+		}
+		return s.Index, nil
+	}
+	ih, exists := s.Indexes[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown index %v", name)
+	}
+	return ih, nil
+}
+
+// Reload reloads the index name or all the indexes if name is empty.
+func (s EngineState) Reload(name string) error {
+	if name != "" {
+		ih, err := s.Holder(name)
+		if err != nil {
+			return err
+		}
+		return ih.Reload()
+	}
+	if s.Index != nil {
+		if err := s.Index.Reload(); err != nil {
+			return err
+		}
+	}
+	for _, name := range s.IndexNames() {
+		if err := s.Indexes[name].Reload(); err != nil {
+			return fmt.Errorf("index %v: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// CloseIndexes closes all the indexes. Queries that still use them
+// after a shutdown are waited for at most shutdown_timeout_ms, the
+// indexes they use are left open.
+func (s EngineState) CloseIndexes() {
+	deadline := time.Now().Add(s.confTimeout(defaultShutdownTimeout, "shutdown_timeout_ms"))
+	closeWithin := func(name string, ih *IndexHolder) {
+		if !ih.CloseWithin(time.Until(deadline)) {
+			log.Printf("close index %q: still in use", name)
+		}
+	}
+	if s.Index != nil {
+		closeWithin("", s.Index)
+	}
+	for name, ih := range s.Indexes {
+		closeWithin(name, ih)
+	}
+}
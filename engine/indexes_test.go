@@ -0,0 +1,25 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"testing"
+	"github.com/art4711/bconf"
+)
+
+func TestOpenIndexesError(t *testing.T) {
+	for _, conf := range []bconf.Bconf{
+		{"index": bconf.Bconf{"ads": bconf.Bconf{"db_name": "/nonexistent"}}},
+		{"broker": bconf.Bconf{"all": bconf.Bconf{"timeout_ms": "x"}}},
+	} {
+		s := EngineState{Conf: conf}
+		if err := s.OpenIndexes(); err == nil {
+			t.Errorf("%v: no error", conf)
+		}
+		if len(s.Indexes) != 0 || len(s.Brokers) != 0 {
+			t.Errorf("%v: failed index kept: %v %v", conf, s.Indexes, s.Brokers)
+		}
+		s.CloseIndexes()
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"net"
+	"time"
+)
+
+/*
+ * Connections to the keepalive port can send any number of queries,
+ * framed like on the search port. The result of every query ends with
+ * the line "info:end".
+ */
+
+const keepaliveEnd = "info:end\n"
+
+func (s EngineState) KeepaliveListener() {
+	s.serve(s.Conf.GetString("port", "keepalive"), s.handleKeepalive)
+}
+
+func (s EngineState) handleKeepalive(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	max := s.confInt(defaultMaxQuerySize, "max_query_size")
+	idle := s.confTimeout(defaultIdleTimeout, "timeout", "idle_ms")
+	wtimeout := s.confTimeout(defaultWriteTimeout, "timeout", "write_ms")
+
+	for {
+		qt := s.Timer.Start("keepalive")
+		et := qt.Start("read")
+		conn.SetReadDeadline(time.Now().Add(idle))
+		// Checked after setting the deadline so that Shutdown can't
+		// be overridden by it.
+		if s.Life.Closing() {
+			et.Stop()
+			qt.Stop()
+			return
+		}
+		q, err := readFrame(r, max)
+		et.Stop()
+		conn.SetWriteDeadline(time.Now().Add(wtimeout))
+		if err != nil {
+			qt.Stop()
+			if err == io.EOF || s.Life.Closing() {
+				return
+			}
+			// The connection can't be trusted to be in sync anymore.
+			log.Printf("keepalive: %v", err)
+			writeError(writer, "read", err)
+			writer.WriteString(keepaliveEnd)
+			writer.Flush()
+			return
+		}
+		s.classicQuery(writer, q, "keepalive", qt)
+		writer.WriteString(keepaliveEnd)
+		err = writer.Flush()
+		qt.Stop()
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"github.com/art4711/timers"
+)
+
+func TestKeepalive(t *testing.T) {
+	s := EngineState{Index: testIndex(t, 1, 2, 3), Timer: timers.NewMemStats()}
+	client, server := net.Pipe()
+	go s.handleKeepalive(server)
+	defer client.Close()
+
+	go func() {
+		client.Write([]byte("lim:1 category:1000\n#19\nlim:2 category:1000"))
+	}()
+
+	r := bufio.NewReader(client)
+	result := func() string {
+		var lines []string
+		for {
+			l, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l == keepaliveEnd {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, l)
+		}
+	}
+	if res := result(); res != "id\tcategory\n3\t1000\n" {
+		t.Errorf("first result: %q", res)
+	}
+	if res := result(); res != "id\tcategory\n3\t1000\n2\t1000\n" {
+		t.Errorf("second result: %q", res)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+ * Lifecycle keeps track of the listeners and connections of the engine
+ * so that it can be shut down without dropping queries. Shutdown stops
+ * accepting new connections, waits for running queries to finish and
+ * then returns. Connections on the keepalive port are closed when they
+ * are done with the query they are running.
+ *
+ * The time to wait for queries is shutdown_timeout_ms in the config.
+ */
+
+const defaultShutdownTimeout = 10 * time.Second
+
+type Lifecycle struct {
+	mtx       sync.Mutex
+	closing   bool
+	listeners []net.Listener
+	servers   []*http.Server
+	conns     map[net.Conn]bool
+	active    sync.WaitGroup
+}
+
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{conns: make(map[net.Conn]bool)}
+}
+
+func (l *Lifecycle) addListener(ln net.Listener) bool {
+	if l == nil {
+		return true
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.closing {
+		ln.Close()
+		return false
+	}
+	l.listeners = append(l.listeners, ln)
+	return true
+}
+
+func (l *Lifecycle) addServer(hs *http.Server) bool {
+	if l == nil {
+		return true
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.closing {
+		return false
+	}
+	l.servers = append(l.servers, hs)
+	return true
+}
+
+// start registers a connection, done must be called when it's closed.
+func (l *Lifecycle) start(conn net.Conn) bool {
+	if l == nil {
+		return true
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.closing {
+		return false
+	}
+	l.conns[conn] = true
+	l.active.Add(1)
+	return true
+}
+
+func (l *Lifecycle) done(conn net.Conn) {
+	if l == nil {
+		return
+	}
+	l.mtx.Lock()
+	delete(l.conns, conn)
+	l.mtx.Unlock()
+	l.active.Done()
+}
+
+// Closing tells if the engine is shutting down.
+func (l *Lifecycle) Closing() bool {
+	if l == nil {
+		return false
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.closing
+}
+
+// Shutdown stops the listeners and waits up to timeout for the running
+// queries to finish. It returns false if they didn't finish in time.
+func (l *Lifecycle) Shutdown(timeout time.Duration) bool {
+	l.mtx.Lock()
+	l.closing = true
+	for _, ln := range l.listeners {
+		ln.Close()
+	}
+	// Connections waiting for their next query stop waiting.
+	for conn := range l.conns {
+		conn.SetReadDeadline(time.Now())
+	}
+	servers := l.servers
+	l.mtx.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, hs := range servers {
+		wg.Add(1)
+		go func(hs *http.Server) {
+			defer wg.Done()
+			hs.Shutdown(ctx)
+		}(hs)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.active.Wait()
+	}()
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
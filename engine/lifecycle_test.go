@@ -0,0 +1,87 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+	"github.com/art4711/timers"
+)
+
+func TestShutdown(t *testing.T) {
+	s := EngineState{Index: testIndex(t, 1, 2, 3), Timer: timers.NewMemStats(), Life: NewLifecycle()}
+
+	// A keepalive connection that has finished one query and waits for the next.
+	client, server := net.Pipe()
+	defer client.Close()
+	if !s.Life.start(server) {
+		t.Fatal("start failed")
+	}
+	go func() {
+		defer s.Life.done(server)
+		s.handleKeepalive(server)
+	}()
+	go client.Write([]byte("lim:1 category:1000\n"))
+	r := bufio.NewReader(client)
+	for {
+		l, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l == keepaliveEnd {
+			break
+		}
+	}
+
+	if !s.Life.Shutdown(time.Second) {
+		t.Fatal("shutdown didn't drain idle connection")
+	}
+	if !s.Life.Closing() {
+		t.Error("not closing after shutdown")
+	}
+	c2, _ := net.Pipe()
+	if s.Life.start(c2) {
+		t.Error("connection started after shutdown")
+	}
+
+	// A query that doesn't finish in time.
+	l := NewLifecycle()
+	_, busy := net.Pipe()
+	l.start(busy)
+	if l.Shutdown(10 * time.Millisecond) {
+		t.Error("shutdown didn't time out")
+	}
+}
+
+func TestStopTwice(t *testing.T) {
+	cchan := make(chan string, 1)
+	done := make(chan struct{})
+	go func() {
+		Stop(cchan)
+		Stop(cchan)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("second stop blocked")
+	}
+	if c := <-cchan; c != "stop" {
+		t.Errorf("got %q", c)
+	}
+}
+
+func TestCloseWithin(t *testing.T) {
+	ih := testIndex(t, 1, 2, 3)
+	_, release := ih.Acquire()
+	if ih.CloseWithin(10 * time.Millisecond) {
+		t.Error("closed while in use")
+	}
+	release()
+	if !ih.CloseWithin(time.Second) {
+		t.Error("not closed after release")
+	}
+}
@@ -5,23 +5,49 @@ package engine
 
 import (
 	"bsearch/parser"
+	"context"
 	"fmt"
 	"net/http"
 	"encoding/json"
 	"log"
 )
 
+// jsonHeaders collects headers for the JSON result, headers
+// with subkeys become nested objects.
+type jsonHeaders map[string]interface{}
+
+func (h jsonHeaders) Add(k, v string) {
+	h[k] = v
+}
+
+func (h jsonHeaders) AddSub(k, sk, v string) {
+	sub, ok := h[k].(map[string]string)
+	if !ok {
+		sub = make(map[string]string)
+		h[k] = sub
+	}
+	sub[sk] = v
+}
+
 func (s EngineState) ListenHTTP() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/x", s.HandleHTTPQuery)
 
 	addr := ":" + s.Conf.GetString("port", "http_search")
-	hs := http.Server{
+	read := s.confTimeout(defaultReadTimeout, "timeout", "read_ms")
+	query, _ := s.queryTimeout("http_search", "")
+	hs := &http.Server{
 		Addr: addr,
 		Handler: mux,
+		ReadTimeout: read,
+		WriteTimeout: read + query + s.confTimeout(defaultWriteTimeout, "timeout", "write_ms"),
+		IdleTimeout: s.confTimeout(defaultIdleTimeout, "timeout", "idle_ms"),
+		// The query is in the url.
+		MaxHeaderBytes: s.confInt(defaultMaxQuerySize, "max_query_size") + 4096,
+	}
+	if s.Life.addServer(hs) {
+		hs.ListenAndServe()
 	}
-	hs.ListenAndServe()
-
 }
 
 func (s EngineState) HandleHTTPQuery(w http.ResponseWriter, req *http.Request) {
@@ -31,10 +57,47 @@ func (s EngineState) HandleHTTPQuery(w http.ResponseWriter, req *http.Request) {
 	et := qt.Start("req")
 
 	result := make(map[string]interface{})
-	resultInfo := make(headers)
+	resultInfo := make(jsonHeaders)
+
+	fail := func(status int, err error) {
+		resultInfo.Add("error", err.Error())
+		result["info"] = resultInfo
+		json, _ := json.Marshal(result)
+		w.WriteHeader(status)
+		w.Write(json)
+		et.Stop()
+	}
 
+	src, err := s.source(req.FormValue("index"))
+	if err != nil {
+		fail(http.StatusNotFound, err)
+		return
+	}
+	timeout, err := s.queryTimeout("http_search", req.FormValue("timeout_ms"))
+	if err != nil {
+		fail(http.StatusBadRequest, err)
+		return
+	}
+	// The query also stops if the client goes away.
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	et = et.Handover("queue")
+	if err := s.Admit.enter(ctx); err != nil {
+		fail(http.StatusServiceUnavailable, err)
+		return
+	}
 	et = et.Handover("parse")
-	q, errsl := parser.Structured(s.Index, req.FormValue("q"), et)
+	o, errsl := parser.ParseStructured(req.FormValue("q"), et)
+	var hits []hit
+	var fields []string
+	if errsl == nil {
+		var release func()
+		et = et.Handover("query")
+		hits, fields, release, errsl = src.query(ctx, o, resultInfo, et)
+		defer release()
+	}
+	s.Admit.leave()
 	if errsl != nil {
 		et = et.Handover("parseError")
 		resultInfo.Add("error", "parse error")
@@ -42,18 +105,22 @@ func (s EngineState) HandleHTTPQuery(w http.ResponseWriter, req *http.Request) {
 			resultInfo.Add(fmt.Sprintf("parse_error%v", k), fmt.Sprint(v))
 		}
 	} else {
-		et = et.Handover("perform")
-		docarr := performQuery(q, et)
-
-		et = et.Handover("ProcessHeaders")
-		q.ProcessHeaders(resultInfo)
-
 		et = et.Handover("BuildDocs")
 		docsData := make(map[string]interface{})
-		for _, d := range docarr {
-			docsData[fmt.Sprint(d.Id)] = s.Index.SplitDoc(d.Id)
+		order := make([]string, 0, len(hits))
+		sortKeys := make([][2]uint32, 0, len(hits))
+		for _, ht := range hits {
+			id := fmt.Sprint(ht.doc.Id)
+			docsData[id] = ht.split()
+			order = append(order, id)
+			sortKeys = append(sortKeys, [2]uint32{ht.doc.Order, ht.doc.Suborder})
 		}
 		result["docs"] = docsData
+		// docs is an object, this keeps the order of the result.
+		result["order"] = order
+		// Order and Suborder of the docs, brokers merge results with them.
+		result["sort_keys"] = sortKeys
+		result["fields"] = fields
 	}
 
 	result["info"] = resultInfo
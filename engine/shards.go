@@ -0,0 +1,199 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"bsearch/ops"
+	"bsearch/parser/opers"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"github.com/art4711/timers"
+)
+
+// hit is a document found by a query and the shard it was found in.
+// Hits from the backends of a broker have the fields of the document
+// instead of a shard.
+type hit struct {
+	doc    *index.IbDoc
+	in     *index.Index
+	fields map[string]string
+}
+
+// data returns the document as the tab separated values of fields.
+func (ht hit) data(fields []string) ([]byte, bool) {
+	if ht.in != nil {
+		return ht.in.Doc(ht.doc.Id)
+	}
+	vals := make([]string, len(fields))
+	for i, f := range fields {
+		vals[i] = ht.fields[f]
+	}
+	return []byte(strings.Join(vals, "\t")), true
+}
+
+// split returns the non-empty fields of the document.
+func (ht hit) split() map[string]string {
+	if ht.in != nil {
+		return ht.in.SplitDoc(ht.doc.Id)
+	}
+	return ht.fields
+}
+
+// source is something queries run on, an index or a broker. The hits
+// are valid until release is called. When ctx is done the query stops
+// and returns what it has with info:timeout.
+type source interface {
+	query(ctx context.Context, o *opers.Op, hc ops.HeaderCollector, et *timers.Event) (hits []hit, fields []string, release func(), errs []error)
+}
+
+func (ih *IndexHolder) query(ctx context.Context, o *opers.Op, hc ops.HeaderCollector, et *timers.Event) ([]hit, []string, func(), []error) {
+	shards, release := ih.Acquire()
+	fields := strings.Split(strings.TrimSuffix(shards[0].Header(), "\n"), "\t")
+	snaps := make([]*index.Index, len(shards))
+	for i, in := range shards {
+		snaps[i] = in.Snapshot()
+	}
+	hits, errs := query(ctx, snaps, o, hc, et)
+	return hits, fields, release, errs
+}
+
+// query runs the query o on the shards of an index and returns the
+// hits in order. With more than one shard the query runs on all of them
+// in parallel, the hits are merged and the headers added up.
+func query(ctx context.Context, shards []*index.Index, o *opers.Op, hc ops.HeaderCollector, et *timers.Event) ([]hit, []error) {
+	if len(shards) == 1 {
+		t := et.Start("generate")
+		dl := ops.NewDeadline(ctx)
+		rk := ops.NewRanks()
+		q, errs := o.GenerateDeadline(shards[0], dl, rk)
+		if errs != nil {
+			t.Stop()
+			return nil, errs
+		}
+		t = t.Handover("performQuery")
+		docarr := performQuery(q, dl, t)
+		rk.Restore(docarr)
+		t = t.Handover("ProcessHeaders")
+		q.ProcessHeaders(hc)
+		if dl.Expired() {
+			hc.Add("timeout", "1")
+		}
+		t.Stop()
+		hits := make([]hit, len(docarr))
+		for i, d := range docarr {
+			hits[i] = hit{doc: d, in: shards[0]}
+		}
+		return hits, nil
+	}
+
+	sq, offset, limit, err := o.Sharded()
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	t := et.Start("shards")
+	qs := make([]ops.QueryOp, len(shards))
+	dls := make([]*ops.Deadline, len(shards))
+	docarrs := make([][]*index.IbDoc, len(shards))
+	errs := make([][]error, len(shards))
+	var wg sync.WaitGroup
+	for i := range shards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dls[i] = ops.NewDeadline(ctx)
+			qs[i], errs[i] = sq.GenerateDeadline(shards[i], dls[i], nil)
+			if errs[i] == nil {
+				docarrs[i] = performQuery(qs[i], dls[i], nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, e := range errs {
+		if e != nil {
+			t.Stop()
+			return nil, e
+		}
+	}
+
+	t = t.Handover("merge")
+	var hits []hit
+	for i, docarr := range docarrs {
+		for _, d := range docarr {
+			hits = append(hits, hit{doc: d, in: shards[i]})
+		}
+	}
+	hits = merge(hits, offset, limit)
+
+	t = t.Handover("ProcessHeaders")
+	sh := make(sumHeaders)
+	for _, q := range qs {
+		q.ProcessHeaders(sh)
+	}
+	sh.replay(hc)
+	for _, dl := range dls {
+		if dl.Expired() {
+			hc.Add("timeout", "1")
+			break
+		}
+	}
+	t.Stop()
+	return hits, nil
+}
+
+// merge sorts the hits from several shards and applies offset and limit.
+func merge(hits []hit, offset, limit int64) []hit {
+	sort.SliceStable(hits, func(i, j int) bool { return hits[j].doc.Less(*hits[i].doc) })
+	if offset > int64(len(hits)) {
+		offset = int64(len(hits))
+	}
+	hits = hits[offset:]
+	if limit != -1 && limit < int64(len(hits)) {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+type headerKey struct {
+	key, subkey string
+	sub         bool
+}
+
+// sumHeaders collects the headers from the shards of an index. Numeric
+// values of the same header, like counters, are added up.
+type sumHeaders map[headerKey]string
+
+func (sh sumHeaders) add(k headerKey, v string) {
+	if old, exists := sh[k]; exists {
+		a, err1 := strconv.ParseInt(old, 10, 64)
+		b, err2 := strconv.ParseInt(v, 10, 64)
+		if err1 == nil && err2 == nil {
+			v = fmt.Sprint(a + b)
+		}
+	}
+	sh[k] = v
+}
+
+func (sh sumHeaders) Add(k, v string) {
+	sh.add(headerKey{key: k}, v)
+}
+
+func (sh sumHeaders) AddSub(k, sk, v string) {
+	sh.add(headerKey{k, sk, true}, v)
+}
+
+func (sh sumHeaders) replay(hc ops.HeaderCollector) {
+	for k, v := range sh {
+		if k.sub {
+			hc.AddSub(k.key, k.subkey, v)
+		} else {
+			hc.Add(k.key, v)
+		}
+	}
+}
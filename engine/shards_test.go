@@ -0,0 +1,131 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"bsearch/parser"
+	"bsearch/parser/opers"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+	"github.com/art4711/timers"
+)
+
+// testShards opens an index with the documents ids spread over n
+// shards, all in category 1000.
+func testShards(t *testing.T, n int, ids ...uint32) *IndexHolder {
+	ws := make([]*index.Writer, n)
+	for i := range ws {
+		ws[i] = index.NewWriter([]string{"id", "category"})
+	}
+	for i, id := range ids {
+		ws[i%n].AddDocument(index.IbDoc{Id: id, Order: id}, []string{fmt.Sprint(id), "1000"}, []string{"category:1000"}, nil)
+	}
+	var names []string
+	for i, w := range ws {
+		name := filepath.Join(t.TempDir(), fmt.Sprintf("db.blob.%d", i))
+		if err := w.WriteFile(name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	ih, err := NewIndexHolder(index.Open, names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ih.Close)
+	return ih
+}
+
+func TestShardedCounters(t *testing.T) {
+	var ids []uint32
+	for i := uint32(1); i <= 40; i++ {
+		ids = append(ids, i)
+	}
+	one := testShards(t, 1, ids...)
+	four := testShards(t, 4, ids...)
+	et := timers.NewMemStats().Start("query")
+	defer et.Stop()
+
+	run := func(ih *IndexHolder, q string) string {
+		o, errs := parser.ParseClassic(q)
+		if errs != nil {
+			t.Fatal(errs)
+		}
+		h := make(headers)
+		hits, _, release, errs := ih.query(context.Background(), o, h, et)
+		defer release()
+		if errs != nil {
+			t.Fatal(errs)
+		}
+		var res []uint32
+		for _, ht := range hits {
+			res = append(res, ht.doc.Id)
+		}
+		return fmt.Sprintf("%v n=%v", res, h["n"])
+	}
+
+	for _, c := range []struct {
+		q, res string
+	}{
+		{"lim:5 count_all(n) category:1000", "[40 39 38 37 36] n=40"},
+		{"2 lim:5 count_all(n) category:1000", "[38 37 36 35 34] n=40"},
+		{"38 lim:5 count_all(n) category:1000", "[2 1] n=40"},
+		{"50 lim:5 count_all(n) category:1000", "[] n=40"},
+	} {
+		if r := run(one, c.q); r != c.res {
+			t.Errorf("%q on one shard: %v, expected %v", c.q, r, c.res)
+		}
+		if r := run(four, c.q); r != c.res {
+			t.Errorf("%q on four shards: %v, expected %v", c.q, r, c.res)
+		}
+	}
+}
+
+// Sort and rand are refused on sharded indexes and brokers with an
+// error that says so.
+func TestShardedReorder(t *testing.T) {
+	s := EngineState{Index: testShards(t, 2, 1, 2, 3, 4), Timer: timers.NewMemStats()}
+	client, server := net.Pipe()
+	go s.handle(server)
+	defer client.Close()
+	go client.Write([]byte("lim:2 sort:-id category:1000\n"))
+	b, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := string(b); r != "info:error:0:"+opers.ErrShardReorder.Error()+"\n" {
+		t.Errorf("tcp: %q", r)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(s.HandleHTTPQuery))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/x?" + url.Values{"q": {`(sort "id" (attr "category:1000"))`}}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var r map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if e := r["info"].(map[string]interface{})["parse_error0"]; e != opers.ErrShardReorder.Error() {
+		t.Errorf("http: %v", r["info"])
+	}
+
+	br := NewBroker([]string{backend(t, 1, 2).URL}, "", time.Second)
+	r = brokerQuery(t, br, `(rand [ 4711 ] (attr "category:1000"))`)
+	if e := r["info"].(map[string]interface{})["parse_error0"]; e != opers.ErrShardReorder.Error() {
+		t.Errorf("broker: %v", r["info"])
+	}
+}
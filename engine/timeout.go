@@ -0,0 +1,60 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ * Queries that run for too long are stopped and return the documents
+ * and counters found so far together with info:timeout. The time a
+ * query may run is configured for all ports and for each port:
+ *
+ *   timeout.query_ms=5000
+ *   timeout.keepalive.query_ms=1000
+ *
+ * A query can ask for less time, but not more, with "timeout=<ms> " in
+ * front of a classic query (after "@name ") or timeout_ms in an http
+ * query.
+ */
+
+var ErrBadTimeout = errors.New("bad timeout")
+
+const defaultQueryTimeout = 5 * time.Second
+
+const timeoutPrefix = "timeout="
+
+// queryTimeout returns the time a query on port may run. asked is the
+// timeout in milliseconds asked for by the query, if any.
+func (s EngineState) queryTimeout(port, asked string) (time.Duration, error) {
+	t := s.confTimeout(s.confTimeout(defaultQueryTimeout, "timeout", "query_ms"), "timeout", port, "query_ms")
+	if asked == "" {
+		return t, nil
+	}
+	ms, err := strconv.Atoi(asked)
+	if err != nil || ms <= 0 {
+		return 0, ErrBadTimeout
+	}
+	if at := time.Duration(ms) * time.Millisecond; at < t {
+		t = at
+	}
+	return t, nil
+}
+
+// splitTimeout splits off the "timeout=<ms> " in front of a query.
+func splitTimeout(q string) (string, string) {
+	if !strings.HasPrefix(q, timeoutPrefix) {
+		return "", q
+	}
+	q = q[len(timeoutPrefix):]
+	i := strings.IndexByte(q, ' ')
+	if i == -1 {
+		return q, ""
+	}
+	return q[:i], q[i+1:]
+}
@@ -0,0 +1,81 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/parser"
+	"bufio"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+	"github.com/art4711/bconf"
+	"github.com/art4711/timers"
+)
+
+func TestQueryTimeout(t *testing.T) {
+	ih := testIndex(t, 1, 2, 3)
+	o, errs := parser.ParseClassic("count_all(n) category:1000")
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	et := timers.NewMemStats().Start("query")
+	defer et.Stop()
+
+	h := make(headers)
+	hits, _, release, errs := ih.query(context.Background(), o, h, et)
+	release()
+	if errs != nil || len(hits) != 3 || h["n"] != "3" || h["timeout"] != "" {
+		t.Errorf("query: %v %v %v", errs, len(hits), h)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h = make(headers)
+	hits, _, release, errs = ih.query(ctx, o, h, et)
+	release()
+	if errs != nil || len(hits) != 0 || h["timeout"] != "1" {
+		t.Errorf("cancelled query: %v %v %v", errs, len(hits), h)
+	}
+}
+
+func TestQueryTimeoutConf(t *testing.T) {
+	s := EngineState{Conf: bconf.Bconf{"timeout": bconf.Bconf{"query_ms": "1000", "keepalive": bconf.Bconf{"query_ms": "200"}}}}
+
+	for _, c := range []struct {
+		port, asked string
+		t           time.Duration
+	}{
+		{"search", "", time.Second},
+		{"keepalive", "", 200 * time.Millisecond},
+		{"search", "50", 50 * time.Millisecond},
+		{"keepalive", "5000", 200 * time.Millisecond},
+	} {
+		to, err := s.queryTimeout(c.port, c.asked)
+		if err != nil || to != c.t {
+			t.Errorf("queryTimeout(%q, %q) = %v, %v, expected %v", c.port, c.asked, to, err, c.t)
+		}
+	}
+	if _, err := s.queryTimeout("search", "x"); err != ErrBadTimeout {
+		t.Errorf("bad timeout: %v", err)
+	}
+
+	s.Index = testIndex(t, 1, 2, 3)
+	s.Timer = timers.NewMemStats()
+	classic := func(q string) string {
+		var b bytes.Buffer
+		w := bufio.NewWriter(&b)
+		qt := s.Timer.Start("query")
+		s.classicQuery(w, q, "search", qt)
+		qt.Stop()
+		w.Flush()
+		return b.String()
+	}
+	if r := classic("timeout=100 lim:1 category:1000"); r != "id\tcategory\n3\t1000\n" {
+		t.Errorf("query with timeout: %q", r)
+	}
+	if r := classic("timeout=x lim:1 category:1000"); r != "info:error:timeout:bad timeout\n" {
+		t.Errorf("bad timeout: %q", r)
+	}
+}
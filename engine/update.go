@@ -0,0 +1,93 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/*
+ * Documents are added and deleted between rebuilds of the index by
+ * posting to /update?index=<name> on the command port:
+ *
+ *   {
+ *     "add": [ { "id": 17, "order": 17, "fields": { "id": "17", "title": "red car" },
+ *                "attrs": [ "category:1000" ], "words": [ "red", "car" ] } ],
+ *     "delete": [ 4711 ]
+ *   }
+ *
+ * Adding a document with an id that already exists replaces it. The
+ * updates are kept in the overlay of the index and are lost when the
+ * index is reloaded, the new index is expected to have them.
+ * In sharded indexes documents go to the shard id % <number of shards>
+ * like with bsearch-index.
+ */
+
+type updateDoc struct {
+	Id       uint32            `json:"id"`
+	Order    uint32            `json:"order"`
+	Suborder uint32            `json:"suborder"`
+	Fields   map[string]string `json:"fields"`
+	Attrs    []string          `json:"attrs"`
+	Words    []string          `json:"words"`
+}
+
+type update struct {
+	Add    []updateDoc `json:"add"`
+	Delete []uint32    `json:"delete"`
+}
+
+func (s EngineState) HandleUpdate(w http.ResponseWriter, req *http.Request) {
+	t := s.Timer.Start("update")
+	defer t.Stop()
+
+	if req.Method != "POST" {
+		http.Error(w, "update must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+	ih, err := s.Holder(req.FormValue("index"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var u update
+	if err := json.NewDecoder(req.Body).Decode(&u); err != nil {
+		http.Error(w, fmt.Sprintf("update: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	shards, release := ih.Acquire()
+	defer release()
+
+	// Each shard gets all its updates at once, the overlay merges
+	// every changed posting once per batch.
+	batches := make([]index.Batch, len(shards))
+	fields := strings.Split(strings.TrimSuffix(shards[0].Header(), "\n"), "\t")
+	for _, d := range u.Add {
+		data := make([]string, len(fields))
+		for i, f := range fields {
+			data[i] = d.Fields[f]
+		}
+		// Split and lower case the words like bsearch-index does.
+		var words []string
+		for _, w := range d.Words {
+			words = append(words, index.SplitWords(w)...)
+		}
+		doc := index.IbDoc{Id: d.Id, Order: d.Order, Suborder: d.Suborder}
+		batches[d.Id%uint32(len(shards))].AddDocument(doc, data, d.Attrs, words)
+	}
+	for _, id := range u.Delete {
+		batches[id%uint32(len(shards))].Delete(id)
+	}
+	for i := range shards {
+		if batches[i].Len() > 0 {
+			shards[i].Overlay().Apply(&batches[i])
+		}
+	}
+	fmt.Fprintf(w, "ok %d added %d deleted\n", len(u.Add), len(u.Delete))
+}
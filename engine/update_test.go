@@ -0,0 +1,80 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package engine
+
+import (
+	"bsearch/index"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"github.com/art4711/timers"
+)
+
+func TestUpdate(t *testing.T) {
+	w := index.NewWriter([]string{"id", "title"})
+	w.AddDocument(index.IbDoc{Id: 1, Order: 1}, []string{"1", "red bicycle"}, []string{"category:1000"}, []string{"red", "bicycle"})
+	w.AddDocument(index.IbDoc{Id: 2, Order: 2}, []string{"2", "red car"}, []string{"category:1000"}, []string{"red", "car"})
+	w.AddDocument(index.IbDoc{Id: 3, Order: 3}, []string{"3", "blue car"}, []string{"category:2000"}, []string{"blue", "car"})
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatal(err)
+	}
+	ih, err := NewIndexHolder(index.Open, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ih.Close()
+	s := EngineState{Index: ih, Timer: timers.NewMemStats()}
+	srv := httptest.NewServer(http.HandlerFunc(s.HandleHTTPQuery))
+	defer srv.Close()
+	usrv := httptest.NewServer(http.HandlerFunc(s.HandleUpdate))
+	defer usrv.Close()
+
+	q := func(q string) string {
+		resp, err := http.Get(srv.URL + "/x?" + url.Values{"q": {q}}.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var r map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Sprint(r["order"])
+	}
+
+	// Replace 2, add 4 and delete 1.
+	u := `{"add": [
+		{"id": 2, "order": 2, "fields": {"id": "2", "title": "blue boat"}, "attrs": ["category:2000"], "words": ["blue", "boat"]},
+		{"id": 4, "order": 4, "fields": {"id": "4", "title": "red car"}, "attrs": ["category:1000"], "words": ["Red", "CAR", "E-mail"]}
+	], "delete": [1]}`
+	resp, err := http.Post(usrv.URL+"/update", "application/json", strings.NewReader(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update: %v", resp.Status)
+	}
+
+	tests := []struct{ q, order string }{
+		{`(attr "category:1000")`, "[4]"},
+		{`(attr "category:2000")`, "[3 2]"},
+		{`(word "car")`, "[4 3]"},
+		{`(phrase [ red ] [ car ])`, "[4]"},
+		{`(phrase [ blue ] [ boat ])`, "[2]"},
+		{`(word "e-mail")`, "[4]"},
+		{`(word "mail")`, "[4]"},
+	}
+	for _, tt := range tests {
+		if o := q(tt.q); o != tt.order {
+			t.Errorf("%v: %v, expected %v", tt.q, o, tt.order)
+		}
+	}
+}
@@ -5,17 +5,63 @@ package index
 
 import (
 	"github.com/art4711/bconf"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type Index struct {
 	br     *blob_reader
 	Docs   map[uint32][]byte
 	Attrs  map[string][]IbDoc
+	Words  map[string]Word
 	Meta   bconf.Bconf
 	header string
+	values map[string]attrValues
+	format *blob_format
+	m      *mapped
+	ov     *Overlay
+	ovs    *overlayState
 }
 
+// attrValue is one numeric value of an attribute and the key of it in Attrs.
+type attrValue struct {
+	v   int64
+	key string
+}
+
+type attrValues []attrValue
+
+func (av attrValues) Len() int           { return len(av) }
+func (av attrValues) Less(i, j int) bool { return av[i].v < av[j].v }
+func (av attrValues) Swap(i, j int)      { av[i], av[j] = av[j], av[i] }
+
+// Open opens the index blob name and reads all the documents, attributes
+// and words into the Docs, Attrs and Words maps.
 func Open(name string) (*Index, error) {
+	return OpenWith(name, Options{})
+}
+
+// OpenMapped opens the index blob name without reading it. Docs, Attrs
+// and Words are left empty and Doc, Attr and Word look things up in the
+// blob instead. The documents in the blob must be sorted by id and the
+// attributes and words by name. Blobs written by Writer are, platform
+// blobs are checked.
+func OpenMapped(name string) (*Index, error) {
+	return OpenWith(name, Options{Mapped: true})
+}
+
+// Options for OpenWith.
+// Mapped - open the index like OpenMapped instead of like Open.
+// LegacyVersions - the header versions of platform 2.3-2.5 blobs to
+// accept, all of them are accepted if it's empty.
+type Options struct {
+	Mapped         bool
+	LegacyVersions []uint64
+}
+
+// OpenWith opens the index blob name like Open or OpenMapped.
+func OpenWith(name string, opt Options) (*Index, error) {
 	var in Index
 	var err error
 
@@ -24,23 +70,169 @@ func Open(name string) (*Index, error) {
 		return nil, err
 	}
 
-	in.Docs = make(map[uint32][]byte)
-	for _, d := range in.br.get_documents() {
-		in.Docs[d.Doc.Id] = in.br.get_document_data(&d)
-	}
+	in.ov = newOverlay()
 
-	in.Attrs = make(map[string][]IbDoc)
-	for _, a := range in.br.get_invattrs() {
-		in.Attrs[in.br.get_attr_name(&a)] = in.br.get_attr_docs(&a)
+	in.format, err = blob_format_of(in.br.Hdr, opt.LegacyVersions)
+	if err == nil {
+		if opt.Mapped {
+			err = in.format.mapped(&in)
+		} else {
+			err = in.format.load(&in)
+		}
+	}
+	if err != nil {
+		in.br.close()
+		return nil, err
+	}
+	if in.m == nil {
+		in.build_values()
 	}
-
-	in.Meta.LoadJson(in.br.get_meta())
 
 	in.Header() // Pre-cache the header to avoid race conditions.
 
 	return &in, nil
 }
 
+// build_values collects the numeric values of every attribute name
+// into sorted lists so that ranges don't need to scan all the attributes.
+func (in *Index) build_values() {
+	in.values = make(map[string]attrValues)
+	for k := range in.Attrs {
+		i := strings.Index(k, ":")
+		if i == -1 {
+			continue
+		}
+		v, err := strconv.ParseInt(k[i+1:], 10, 64)
+		if err != nil {
+			continue
+		}
+		in.values[k[:i]] = append(in.values[k[:i]], attrValue{v, k})
+	}
+	for _, av := range in.values {
+		sort.Sort(av)
+	}
+}
+
+// AttrRange returns the keys of the attribute name with numeric values
+// between low and high inclusive, in the blob or the overlay.
+func (in Index) AttrRange(name string, low, high int64) []string {
+	r := in.attr_range(name, low, high)
+	st := in.overlay()
+	if st.attrs.len() == 0 {
+		return r
+	}
+	seen := make(map[string]bool)
+	for _, k := range r {
+		seen[k] = true
+	}
+	prefix := name + ":"
+	st.attrs.each(func(k string, _ []IbDoc) {
+		if !strings.HasPrefix(k, prefix) || seen[k] {
+			return
+		}
+		v, err := strconv.ParseInt(k[len(prefix):], 10, 64)
+		if err == nil && v >= low && v <= high {
+			r = append(r, k)
+		}
+	})
+	return r
+}
+
+func (in Index) attr_range(name string, low, high int64) []string {
+	if in.m != nil {
+		return in.m.attr_range(name, low, high)
+	}
+	av := in.values[name]
+	i := sort.Search(len(av), func(i int) bool { return av[i].v >= low })
+	j := sort.Search(len(av), func(i int) bool { return av[i].v > high })
+	if j <= i {
+		return nil
+	}
+	r := make([]string, 0, j-i)
+	for ; i < j; i++ {
+		r = append(r, av[i].key)
+	}
+	return r
+}
+
+// Overlay returns the overlay of the documents added and deleted since
+// the index was opened.
+func (in Index) Overlay() *Overlay {
+	return in.ov
+}
+
+// Snapshot returns the index with the current state of the overlay that
+// doesn't change with updates to the overlay. Queries should run on a
+// snapshot to see the same documents all the time.
+func (in *Index) Snapshot() *Index {
+	s := *in
+	s.ovs = in.ov.state()
+	return &s
+}
+
+func (in Index) overlay() *overlayState {
+	if in.ovs != nil {
+		return in.ovs
+	}
+	return in.ov.state()
+}
+
+// HasOverlay tells if any documents have been added or deleted in the overlay.
+func (in Index) HasOverlay() bool {
+	return !in.overlay().empty()
+}
+
+// Deleted tells if the document id in the blob has been deleted or
+// replaced by the overlay.
+func (in Index) Deleted(id uint32) bool {
+	deleted, _ := in.overlay().deleted.get(id)
+	return deleted
+}
+
+// OverlayAttr returns the documents in the overlay with the attribute key.
+func (in Index) OverlayAttr(key string) []IbDoc {
+	p, _ := in.overlay().attrs.get(key)
+	return p
+}
+
+// OverlayWord returns the word w in the documents of the overlay.
+func (in Index) OverlayWord(w string) (Word, bool) {
+	return in.overlay().word(w)
+}
+
+// Doc returns the data of the document id.
+func (in Index) Doc(id uint32) ([]byte, bool) {
+	st := in.overlay()
+	if od, exists := st.docs.get(id); exists {
+		return od.data, true
+	}
+	if _, deleted := st.deleted.get(id); deleted {
+		return nil, false
+	}
+	if in.m != nil {
+		return in.m.doc(id)
+	}
+	d, exists := in.Docs[id]
+	return d, exists
+}
+
+// Attr returns the documents in the blob with the attribute key.
+func (in Index) Attr(key string) []IbDoc {
+	if in.m != nil {
+		return in.m.attr(key)
+	}
+	return in.Attrs[key]
+}
+
+// Word returns the inverted index of the word w in the blob.
+func (in Index) Word(w string) (Word, bool) {
+	if in.m != nil {
+		return in.m.word(w)
+	}
+	iw, exists := in.Words[w]
+	return iw, exists
+}
+
 func (in Index) Header() string {
 	if in.header == "" {
 		in.Meta.GetNode("attr", "order").ForeachSorted(func(k, v string) {
@@ -54,6 +246,11 @@ func (in Index) Header() string {
 	return in.header
 }
 
+// Format describes the version of the blob the index was read from.
+func (in Index) Format() string {
+	return in.format.name
+}
+
 func (in Index) Close() {
 	in.br.close()
 }
@@ -8,6 +8,12 @@ package index
  * The data structures here all have the Ib prefix (ib = index blob).
  */
 
+// Magic and Version of the blobs written by Writer.
+const (
+	IbMagic   = 0x5844494843525342 // "BSRCHIDX"
+	IbVersion = 1
+)
+
 // IbHeader is the header of the on-disk index. Contains file offsets to the other relevant data structures.
 type IbHeader struct {
 	Magic   uint64
@@ -76,12 +82,24 @@ type IbDocindex struct {
 	Posptr uint32
 }
 
+// IbDocpos is the element of the per-word docpos array.
+// Flags - IbDocposLast marks the last position of a document.
+// Pos - position of the word in the document.
+// Rel_boost - relevance boost of this occurrence.
 type IbDocpos struct {
 	Flags     uint16
 	Pos       uint16
 	Rel_boost uint16
 }
 
+// Set in IbDocpos.Flags on the last position of the positions for one document.
+const IbDocposLast = 0x8000
+
+// IbInvword is the element of the invwords array.
+// Docslen - size in bytes of the IbDocindex array of the word.
+// Word_offs - offset in blob to the word string.
+// Docs_offs - offset in blob to the IbDocindex array.
+// Docops_offs - offset in blob to the IbDocpos array.
 type IbInvword struct {
 	Docslen     uint64
 	Word_offs   uint64
@@ -4,12 +4,42 @@
 package index
 
 import (
+	"bytes"
 	"strings"
+	"strconv"
 	"fmt"
 )
 
+// Field returns the column of the field name in the documents or -1
+// if there is no such field.
+func (in Index) Field(name string) int {
+	col := -1
+	in.Meta.GetNode("attr", "order").ForeachSorted(func(k, v string) {
+		if v == name {
+			col, _ = strconv.Atoi(k)
+		}
+	})
+	return col
+}
+
+// DocField returns the value in column col of a document.
+func (in Index) DocField(docId uint32, col int) string {
+	d, _ := in.Doc(docId)
+	for ; col > 0; col-- {
+		i := bytes.IndexByte(d, '\t')
+		if i == -1 {
+			return ""
+		}
+		d = d[i+1:]
+	}
+	if i := bytes.IndexByte(d, '\t'); i != -1 {
+		d = d[:i]
+	}
+	return string(d)
+}
+
 func (in Index) SplitDoc(docId uint32) map[string]string {
-	d, exists := in.Docs[docId]
+	d, exists := in.Doc(docId)
 	if !exists {
 		return nil
 	}
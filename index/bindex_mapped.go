@@ -0,0 +1,159 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package index
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+)
+
+/*
+ * mapped answers the lookups in the index straight from the arrays in
+ * the blob with binary searches instead of building maps of everything
+ * when the index is opened. This requires the documents to be sorted by
+ * id and the attributes and words to be sorted by name, which is how
+ * Writer lays them out, so blobs with IbMagic are trusted to be sorted.
+ * Platform blobs aren't necessarily sorted so their order is checked
+ * when they are opened and unsorted ones are rejected with ErrUnsorted.
+ * That reads through the arrays and names once, but builds nothing.
+ *
+ * Broken document data and postings found during lookups are treated
+ * as not found.
+ */
+type mapped struct {
+	br       *blob_reader
+	docs     []IbDocument
+	invattrs []IbInvattr
+	invwords []IbInvword
+}
+
+func map_v1(in *Index) error {
+	var err error
+	m := &mapped{br: in.br}
+	if m.docs, err = in.br.get_documents(); err != nil {
+		return err
+	}
+	if m.invattrs, err = in.br.get_invattrs(); err != nil {
+		return err
+	}
+	if m.invwords, err = in.br.get_invwords(); err != nil {
+		return err
+	}
+	in.m = m
+	return load_meta(in)
+}
+
+// check_sorted checks that the arrays are in the order the binary
+// searches need.
+func (m *mapped) check_sorted() error {
+	for i := 1; i < len(m.docs); i++ {
+		if m.docs[i-1].Doc.Id >= m.docs[i].Doc.Id {
+			return &BlobError{"documents", ErrUnsorted}
+		}
+	}
+	var prev []byte
+	for i := range m.invattrs {
+		k, err := m.br.cbytes("attr name", m.invattrs[i].Attr_offs)
+		if err != nil {
+			return err
+		}
+		if i > 0 && bytes.Compare(prev, k) >= 0 {
+			return &BlobError{"invattrs", ErrUnsorted}
+		}
+		prev = k
+	}
+	for i := range m.invwords {
+		w, err := m.br.cbytes("word", m.invwords[i].Word_offs)
+		if err != nil {
+			return err
+		}
+		if i > 0 && bytes.Compare(prev, w) >= 0 {
+			return &BlobError{"invwords", ErrUnsorted}
+		}
+		prev = w
+	}
+	return nil
+}
+
+func (m *mapped) doc(id uint32) ([]byte, bool) {
+	i := sort.Search(len(m.docs), func(i int) bool { return m.docs[i].Doc.Id >= id })
+	if i == len(m.docs) || m.docs[i].Doc.Id != id {
+		return nil, false
+	}
+	d, err := m.br.get_document_data(&m.docs[i])
+	if err != nil {
+		return nil, false
+	}
+	return d, true
+}
+
+func (m *mapped) attr_name(i int) []byte {
+	r, err := m.br.cbytes("attr name", m.invattrs[i].Attr_offs)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// attr_search returns the first attribute not less than key.
+func (m *mapped) attr_search(key []byte) int {
+	return sort.Search(len(m.invattrs), func(i int) bool { return bytes.Compare(m.attr_name(i), key) >= 0 })
+}
+
+func (m *mapped) attr(key string) []IbDoc {
+	i := m.attr_search([]byte(key))
+	if i == len(m.invattrs) || string(m.attr_name(i)) != key {
+		return nil
+	}
+	r, err := m.br.get_attr_docs(&m.invattrs[i])
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// attr_range finds the numeric values of the attribute name between low
+// and high. All the values of an attribute are next to each other in
+// the sorted attributes, but not in numeric order.
+func (m *mapped) attr_range(name string, low, high int64) []string {
+	prefix := []byte(name + ":")
+	var av attrValues
+	for i := m.attr_search(prefix); i < len(m.invattrs); i++ {
+		k := m.attr_name(i)
+		if !bytes.HasPrefix(k, prefix) {
+			break
+		}
+		v, err := strconv.ParseInt(string(k[len(prefix):]), 10, 64)
+		if err != nil || v < low || v > high {
+			continue
+		}
+		av = append(av, attrValue{v, string(k)})
+	}
+	sort.Sort(av)
+	r := make([]string, len(av))
+	for i := range av {
+		r[i] = av[i].key
+	}
+	return r
+}
+
+func (m *mapped) word(w string) (Word, bool) {
+	name := func(i int) string {
+		r, err := m.br.cbytes("word", m.invwords[i].Word_offs)
+		if err != nil {
+			return ""
+		}
+		return string(r)
+	}
+	i := sort.Search(len(m.invwords), func(i int) bool { return name(i) >= w })
+	if i == len(m.invwords) || name(i) != w {
+		return Word{}, false
+	}
+	docs, err := m.br.get_word_docs(&m.invwords[i])
+	if err != nil {
+		return Word{}, false
+	}
+	return Word{Docs: docs, inv: m.invwords[i], end: m.br.word_positions_end(m.invwords, i), br: m.br}, true
+}
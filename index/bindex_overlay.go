@@ -0,0 +1,270 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package index
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+ * The overlay keeps the documents added and deleted since the index
+ * was opened. Added documents get their own attribute and word
+ * postings in memory, documents deleted or replaced are hidden in the
+ * postings of the blob by the deleted set.
+ *
+ * The state of the overlay is never changed, every update makes a new
+ * one that shares all but the changed keys with the old one, see pmap.
+ * A query works on one state from Snapshot and sees the same documents
+ * all the way through while updates go on. A Batch applies many
+ * documents at once.
+ */
+
+type Overlay struct {
+	update sync.Mutex
+	mtx    sync.RWMutex
+	st     *overlayState
+}
+
+type overlayState struct {
+	docs    pmap[uint32, odoc]
+	attrs   pmap[string, []IbDoc]
+	words   pmap[string, []wposting]
+	deleted pmap[uint32, bool]
+
+	// The changes to the postings by the update in progress, they
+	// are merged into attrs and words when it is done.
+	dattrs map[string]*pchange
+	dwords map[string]*pchange
+}
+
+// pchange is the documents added to and removed from one posting, with
+// their positions for words.
+type pchange struct {
+	add map[IbDoc][]uint16
+	del map[IbDoc]bool
+}
+
+// odoc is a document added to the overlay.
+type odoc struct {
+	doc   IbDoc
+	data  []byte
+	attrs []string
+	words []string
+}
+
+func newOverlay() *Overlay {
+	return &Overlay{st: &overlayState{
+		docs:    newPmap[uint32, odoc](hashId),
+		attrs:   newPmap[string, []IbDoc](hashString),
+		words:   newPmap[string, []wposting](hashString),
+		deleted: newPmap[uint32, bool](hashId),
+	}}
+}
+
+func (ov *Overlay) state() *overlayState {
+	ov.mtx.RLock()
+	defer ov.mtx.RUnlock()
+	return ov.st
+}
+
+// change makes a new state from the current one, lets f change it and
+// swaps it in.
+func (ov *Overlay) change(f func(st *overlayState)) {
+	ov.update.Lock()
+	defer ov.update.Unlock()
+
+	st := *ov.state()
+	st.dattrs = make(map[string]*pchange)
+	st.dwords = make(map[string]*pchange)
+	f(&st)
+	st.finish()
+
+	ov.mtx.Lock()
+	ov.st = &st
+	ov.mtx.Unlock()
+}
+
+// Batch is a set of updates applied to an overlay at once with Apply.
+// The updates are applied in the order they were made.
+type Batch struct {
+	ops []batchOp
+}
+
+// batchOp deletes id and adds od if it is set.
+type batchOp struct {
+	id uint32
+	od *odoc
+}
+
+// AddDocument adds a document or replaces the document with the same id.
+// The arguments are the same as for Writer.AddDocument.
+func (b *Batch) AddDocument(doc IbDoc, data []string, attrs []string, words []string) {
+	b.ops = append(b.ops, batchOp{doc.Id, &odoc{doc, []byte(strings.Join(data, "\t")), attrs, words}})
+}
+
+// Delete deletes the document id.
+func (b *Batch) Delete(id uint32) {
+	b.ops = append(b.ops, batchOp{id, nil})
+}
+
+// Len returns the number of updates in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Apply applies the updates in b to the overlay.
+func (ov *Overlay) Apply(b *Batch) {
+	ov.change(func(st *overlayState) {
+		for _, op := range b.ops {
+			st.remove(op.id)
+			st.deleted = st.deleted.set(op.id, true)
+			if op.od != nil {
+				st.add(*op.od)
+			}
+		}
+	})
+}
+
+// AddDocument adds a document or replaces the document with the same id.
+// The arguments are the same as for Writer.AddDocument.
+func (ov *Overlay) AddDocument(doc IbDoc, data []string, attrs []string, words []string) {
+	var b Batch
+	b.AddDocument(doc, data, attrs, words)
+	ov.Apply(&b)
+}
+
+// Delete deletes the document id.
+func (ov *Overlay) Delete(id uint32) {
+	var b Batch
+	b.Delete(id)
+	ov.Apply(&b)
+}
+
+// changes returns the changes to the posting key in m.
+func changes(m map[string]*pchange, key string) *pchange {
+	c, exists := m[key]
+	if !exists {
+		c = &pchange{add: make(map[IbDoc][]uint16), del: make(map[IbDoc]bool)}
+		m[key] = c
+	}
+	return c
+}
+
+func (c *pchange) added(d IbDoc, pos []uint16) {
+	delete(c.del, d)
+	c.add[d] = pos
+}
+
+func (c *pchange) removed(d IbDoc) {
+	delete(c.add, d)
+	c.del[d] = true
+}
+
+// merge returns a new posting with the changes made to the old one
+// without looking at more than the old posting and the changes.
+func merge[P any](old []P, c *pchange, doc func(P) IbDoc, mk func(IbDoc, []uint16) P) []P {
+	add := make([]IbDoc, 0, len(c.add))
+	for d := range c.add {
+		add = append(add, d)
+	}
+	sort.Sort(descending(add))
+	r := make([]P, 0, len(old)+len(add))
+	for _, p := range old {
+		d := doc(p)
+		for len(add) > 0 && d.Less(add[0]) {
+			r = append(r, mk(add[0], c.add[add[0]]))
+			add = add[1:]
+		}
+		if _, replaced := c.add[d]; !replaced && !c.del[d] {
+			r = append(r, p)
+		}
+	}
+	for _, d := range add {
+		r = append(r, mk(d, c.add[d]))
+	}
+	return r
+}
+
+func (st *overlayState) add(od odoc) {
+	st.docs = st.docs.set(od.doc.Id, od)
+	for _, a := range od.attrs {
+		changes(st.dattrs, a).added(od.doc, nil)
+	}
+	pos := make(map[string][]uint16)
+	for i, wd := range od.words {
+		if i > 0xffff {
+			break
+		}
+		if wd == "" {
+			continue
+		}
+		pos[wd] = append(pos[wd], uint16(i))
+	}
+	for wd, wp := range pos {
+		changes(st.dwords, wd).added(od.doc, wp)
+	}
+}
+
+func (st *overlayState) remove(id uint32) {
+	od, exists := st.docs.get(id)
+	if !exists {
+		return
+	}
+	st.docs = st.docs.del(id)
+	for _, a := range od.attrs {
+		changes(st.dattrs, a).removed(od.doc)
+	}
+	for _, wd := range od.words {
+		if wd != "" {
+			changes(st.dwords, wd).removed(od.doc)
+		}
+	}
+}
+
+// finish merges the changes of the update into the postings.
+func (st *overlayState) finish() {
+	for a, c := range st.dattrs {
+		old, _ := st.attrs.get(a)
+		p := merge(old, c, func(d IbDoc) IbDoc { return d }, func(d IbDoc, _ []uint16) IbDoc { return d })
+		if len(p) == 0 {
+			st.attrs = st.attrs.del(a)
+		} else {
+			st.attrs = st.attrs.set(a, p)
+		}
+	}
+	for wd, c := range st.dwords {
+		old, _ := st.words.get(wd)
+		p := merge(old, c, func(wp wposting) IbDoc { return wp.doc }, func(d IbDoc, pos []uint16) wposting { return wposting{d, pos} })
+		if len(p) == 0 {
+			st.words = st.words.del(wd)
+		} else {
+			st.words = st.words.set(wd, p)
+		}
+	}
+	st.dattrs, st.dwords = nil, nil
+}
+
+// word builds the Word of the postings of an overlay word.
+func (st *overlayState) word(wd string) (Word, bool) {
+	p, exists := st.words.get(wd)
+	if !exists {
+		return Word{}, false
+	}
+	w := Word{Docs: make([]IbDocindex, len(p)), pos: make([][]IbDocpos, len(p))}
+	for i := range p {
+		w.Docs[i] = IbDocindex{Doc: p[i].doc, Posptr: uint32(i)}
+		w.pos[i] = make([]IbDocpos, len(p[i].pos))
+		for j, ps := range p[i].pos {
+			w.pos[i][j].Pos = ps
+		}
+		w.pos[i][len(w.pos[i])-1].Flags |= IbDocposLast
+	}
+	return w, true
+}
+
+func (st *overlayState) empty() bool {
+	return st.docs.len() == 0 && st.deleted.len() == 0
+}
@@ -0,0 +1,128 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package index
+
+/*
+ * pmap is the map the overlay keeps its state in. It's never changed,
+ * set and del return a new pmap that shares everything with the old
+ * one except the path to the key. The path is a trie of pmapFanout-way
+ * nodes picked by the hash of the key with small maps in the leaves,
+ * so a change copies pmapLevels nodes and one small map no matter how
+ * big the map is.
+ */
+
+const pmapBits = 4
+const pmapFanout = 1 << pmapBits
+const pmapLevels = 4
+
+type pmap[K comparable, V any] struct {
+	root *pnode[K, V]
+	n    int
+	hash func(K) uint32
+}
+
+type pnode[K comparable, V any] struct {
+	kids [pmapFanout]*pnode[K, V]
+	leaf map[K]V
+}
+
+func newPmap[K comparable, V any](hash func(K) uint32) pmap[K, V] {
+	return pmap[K, V]{hash: hash}
+}
+
+// hashId spreads document ids over the trie.
+func hashId(id uint32) uint32 {
+	return id * 2654435761
+}
+
+// hashString is FNV-1a.
+func hashString(s string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= 16777619
+	}
+	return h
+}
+
+func pslot(h uint32, level int) uint32 {
+	return h >> (32 - pmapBits*(level+1)) & (pmapFanout - 1)
+}
+
+func (m pmap[K, V]) len() int {
+	return m.n
+}
+
+func (m pmap[K, V]) get(k K) (V, bool) {
+	h := m.hash(k)
+	n := m.root
+	for l := 0; l < pmapLevels && n != nil; l++ {
+		n = n.kids[pslot(h, l)]
+	}
+	if n == nil {
+		var zero V
+		return zero, false
+	}
+	v, ok := n.leaf[k]
+	return v, ok
+}
+
+func (m pmap[K, V]) set(k K, v V) pmap[K, V] {
+	if _, exists := m.get(k); !exists {
+		m.n++
+	}
+	m.root = m.root.set(m.hash(k), 0, k, &v)
+	return m
+}
+
+func (m pmap[K, V]) del(k K) pmap[K, V] {
+	if _, exists := m.get(k); !exists {
+		return m
+	}
+	m.n--
+	m.root = m.root.set(m.hash(k), 0, k, nil)
+	return m
+}
+
+// set returns a copy of the subtrie n with k set to v, or deleted if v is nil.
+func (n *pnode[K, V]) set(h uint32, level int, k K, v *V) *pnode[K, V] {
+	c := &pnode[K, V]{}
+	if n != nil {
+		*c = *n
+	}
+	if level == pmapLevels {
+		c.leaf = make(map[K]V, len(c.leaf)+1)
+		if n != nil {
+			for lk, lv := range n.leaf {
+				c.leaf[lk] = lv
+			}
+		}
+		if v == nil {
+			delete(c.leaf, k)
+		} else {
+			c.leaf[k] = *v
+		}
+		return c
+	}
+	i := pslot(h, level)
+	c.kids[i] = c.kids[i].set(h, level+1, k, v)
+	return c
+}
+
+// each calls f for every key and value in the map, in no particular order.
+func (m pmap[K, V]) each(f func(k K, v V)) {
+	m.root.each(f)
+}
+
+func (n *pnode[K, V]) each(f func(k K, v V)) {
+	if n == nil {
+		return
+	}
+	for k, v := range n.leaf {
+		f(k, v)
+	}
+	for _, kid := range n.kids {
+		kid.each(f)
+	}
+}
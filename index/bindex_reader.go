@@ -4,15 +4,36 @@
 package index
 
 import (
+	"errors"
 	"github.com/art4711/filemap"
-	"log"
+	"math"
 	"os"
 	"unsafe"
 )
 
+var ErrCorrupt = errors.New("corrupt blob")
+var ErrTruncated = errors.New("truncated blob")
+var ErrUnsorted = errors.New("not sorted, can't be opened mapped")
+
+// BlobError is returned when a section of the blob is broken.
+// Err is ErrCorrupt, ErrTruncated or ErrUnsorted.
+type BlobError struct {
+	Section string
+	Err     error
+}
+
+func (e *BlobError) Error() string {
+	return "index: " + e.Section + ": " + e.Err.Error()
+}
+
+func (e *BlobError) Unwrap() error {
+	return e.Err
+}
+
 type blob_reader struct {
 	file *os.File
 	fmap *filemap.Map
+	size uint64
 	Hdr  *IbHeader
 }
 
@@ -25,13 +46,24 @@ func open_blob_reader(name string) (*blob_reader, error) {
 		return nil, err
 	}
 
+	fi, err := br.file.Stat()
+	if err != nil {
+		br.file.Close()
+		return nil, err
+	}
+	br.size = uint64(fi.Size())
+
 	br.fmap, err = filemap.NewReader(br.file)
 	if err != nil {
 		br.file.Close()
 		return nil, err
 	}
 
-	br.Hdr = br.get_header()
+	br.Hdr, err = br.get_header()
+	if err != nil {
+		br.close()
+		return nil, err
+	}
 	return &br, nil
 }
 
@@ -40,53 +72,182 @@ func (br *blob_reader) close() {
 	br.file.Close()
 }
 
-func (br *blob_reader) get_header() *IbHeader {
-	return &(*(*[]IbHeader)(br.reslice(unsafe.Sizeof(IbHeader{}), 0, 1, false)))[0]
+// check verifies that n elements of size len at off are inside the blob.
+func (br *blob_reader) check(section string, len uintptr, off, n uint64) error {
+	if n > math.MaxUint64/uint64(len) {
+		return &BlobError{section, ErrCorrupt}
+	}
+	if off > br.size || n*uint64(len) > br.size-off {
+		return &BlobError{section, ErrTruncated}
+	}
+	return nil
 }
 
-func (br *blob_reader) reslice(len uintptr, off, sz uint64, sz_in_bytes bool) unsafe.Pointer {
+func (br *blob_reader) reslice(section string, len uintptr, off, sz uint64, sz_in_bytes bool) (unsafe.Pointer, error) {
 	if sz_in_bytes {
+		if sz%uint64(len) != 0 {
+			return nil, &BlobError{section, ErrCorrupt}
+		}
 		sz /= uint64(len)
 	}
+	if err := br.check(section, len, off, sz); err != nil {
+		return nil, err
+	}
 	r, err := br.fmap.Slice(len, off, sz)
 	if err != nil {
-		log.Fatal("filemap.Slice: %v", err)
+		return nil, &BlobError{section, ErrCorrupt}
 	}
-	return r
+	return r, nil
 }
 
-func (br *blob_reader) get_documents() []IbDocument {
-	return *(*[]IbDocument)(br.reslice(unsafe.Sizeof(IbDocument{}), br.Hdr.documents_off, br.Hdr.ndocuments, false))
+func (br *blob_reader) cbytes(section string, off uint64) ([]byte, error) {
+	if off >= br.size {
+		return nil, &BlobError{section, ErrTruncated}
+	}
+	r, err := br.fmap.CString(off)
+	if err != nil {
+		return nil, &BlobError{section, ErrCorrupt}
+	}
+	return r, nil
 }
 
-func (br *blob_reader) get_document_data(d *IbDocument) []byte {
-	r, err := br.fmap.Bytes(d.Blob_offs, uint64(d.Doclen)-1)
+func (br *blob_reader) cstring(section string, off uint64) (string, error) {
+	r, err := br.cbytes(section, off)
+	return string(r), err
+}
+
+func (br *blob_reader) get_header() (*IbHeader, error) {
+	r, err := br.reslice("header", unsafe.Sizeof(IbHeader{}), 0, 1, false)
 	if err != nil {
-		log.Fatal("get_document_data: %v", err)
+		return nil, err
 	}
-	return r
+	return &(*(*[]IbHeader)(r))[0], nil
 }
 
-func (br *blob_reader) get_invattrs() []IbInvattr {
-	return *(*[]IbInvattr)(br.reslice(unsafe.Sizeof(IbInvattr{}), br.Hdr.invattrs_off, br.Hdr.ninvattrs, false))
+func (br *blob_reader) get_documents() ([]IbDocument, error) {
+	r, err := br.reslice("documents", unsafe.Sizeof(IbDocument{}), br.Hdr.documents_off, br.Hdr.ndocuments, false)
+	if err != nil {
+		return nil, err
+	}
+	return *(*[]IbDocument)(r), nil
 }
 
-func (br *blob_reader) get_attr_name(a *IbInvattr) string {
-	r, err := br.fmap.CString(a.Attr_offs)
+func (br *blob_reader) get_document_data(d *IbDocument) ([]byte, error) {
+	if d.Doclen == 0 {
+		return nil, &BlobError{"document data", ErrCorrupt}
+	}
+	if err := br.check("document data", 1, d.Blob_offs, uint64(d.Doclen)); err != nil {
+		return nil, err
+	}
+	r, err := br.fmap.Bytes(d.Blob_offs, uint64(d.Doclen)-1)
 	if err != nil {
-		log.Fatal("get_attr_name: %v", err)
+		return nil, &BlobError{"document data", ErrCorrupt}
 	}
-	return string(r)
+	return r, nil
 }
 
-func (br *blob_reader) get_attr_docs(a *IbInvattr) []IbDoc {
-	return *(*[]IbDoc)(br.reslice(unsafe.Sizeof(IbDoc{}), a.Docs_offs, a.Docslen, true))
+func (br *blob_reader) get_invattrs() ([]IbInvattr, error) {
+	r, err := br.reslice("invattrs", unsafe.Sizeof(IbInvattr{}), br.Hdr.invattrs_off, br.Hdr.ninvattrs, false)
+	if err != nil {
+		return nil, err
+	}
+	return *(*[]IbInvattr)(r), nil
+}
+
+func (br *blob_reader) get_attr_name(a *IbInvattr) (string, error) {
+	return br.cstring("attr name", a.Attr_offs)
 }
 
-func (br *blob_reader) get_meta() []byte {
+func (br *blob_reader) get_attr_docs(a *IbInvattr) ([]IbDoc, error) {
+	r, err := br.reslice("attr docs", unsafe.Sizeof(IbDoc{}), a.Docs_offs, a.Docslen, true)
+	if err != nil {
+		return nil, err
+	}
+	return *(*[]IbDoc)(r), nil
+}
+
+func (br *blob_reader) get_meta() ([]byte, error) {
+	if err := br.check("meta", 1, br.Hdr.meta_off, br.Hdr.meta_sz); err != nil {
+		return nil, err
+	}
 	r, err := br.fmap.Bytes(br.Hdr.meta_off, br.Hdr.meta_sz)
 	if err != nil {
-		log.Fatal("get_meta: %v", err)
+		return nil, &BlobError{"meta", ErrCorrupt}
+	}
+	return r, nil
+}
+
+func (br *blob_reader) get_invwords() ([]IbInvword, error) {
+	r, err := br.reslice("invwords", unsafe.Sizeof(IbInvword{}), br.Hdr.invwords_off, br.Hdr.ninvwords, false)
+	if err != nil {
+		return nil, err
+	}
+	return *(*[]IbInvword)(r), nil
+}
+
+func (br *blob_reader) get_word(w *IbInvword) (string, error) {
+	return br.cstring("word", w.Word_offs)
+}
+
+func (br *blob_reader) get_word_docs(w *IbInvword) ([]IbDocindex, error) {
+	r, err := br.reslice("word docs", unsafe.Sizeof(IbDocindex{}), w.Docs_offs, w.Docslen, true)
+	if err != nil {
+		return nil, err
+	}
+	docs := *(*[]IbDocindex)(r)
+	if len(docs) > 0 && w.Docops_offs >= br.size {
+		return nil, &BlobError{"word positions", ErrTruncated}
+	}
+	return docs, nil
+}
+
+// word_positions_end returns where the positions of invwords[i] end at
+// the latest. Sections of the blob don't overlap, so the positions end
+// before the header sections or the next word that start after them.
+func (br *blob_reader) word_positions_end(invwords []IbInvword, i int) uint64 {
+	start := invwords[i].Docops_offs
+	end := br.size
+	bound := func(off uint64) {
+		if off > start && off < end {
+			end = off
+		}
+	}
+	bound(br.Hdr.documents_off)
+	bound(br.Hdr.invattrs_off)
+	bound(br.Hdr.invwords_off)
+	bound(br.Hdr.meta_off)
+	bound(invwords[i].Word_offs)
+	bound(invwords[i].Docs_offs)
+	if i+1 < len(invwords) {
+		bound(invwords[i+1].Word_offs)
+		bound(invwords[i+1].Docs_offs)
+		bound(invwords[i+1].Docops_offs)
+	}
+	return end
+}
+
+// get_word_positions returns the positions of the word w in the
+// document di. end is from word_positions_end.
+func (br *blob_reader) get_word_positions(w *IbInvword, end uint64, di *IbDocindex) ([]IbDocpos, error) {
+	sz := uint64(unsafe.Sizeof(IbDocpos{}))
+	off := w.Docops_offs + uint64(di.Posptr)*sz
+	if off < w.Docops_offs || off >= end {
+		return nil, &BlobError{"word positions", ErrTruncated}
+	}
+	/*
+	 * The number of positions isn't stored anywhere, the last position
+	 * of a document is flagged instead. Map everything up to where the
+	 * positions of the word end and cut at the flag.
+	 */
+	r, err := br.reslice("word positions", uintptr(sz), off, (end-off)/sz, false)
+	if err != nil {
+		return nil, err
+	}
+	pos := *(*[]IbDocpos)(r)
+	for i := range pos {
+		if pos[i].Flags&IbDocposLast != 0 {
+			return pos[:i+1], nil
+		}
 	}
-	return r
+	return nil, &BlobError{"word positions", ErrCorrupt}
 }
@@ -0,0 +1,88 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package index
+
+import (
+	"sort"
+)
+
+// Stat is the summary of the blob of an index.
+type Stat struct {
+	Format       string
+	Magic        uint64
+	Version      uint64
+	Size         uint64
+	Documents    uint64
+	Attrs        uint64
+	Words        uint64
+	TotalWordLen uint64
+	MetaSize     uint64
+}
+
+func (in Index) Stat() Stat {
+	h := in.br.Hdr
+	return Stat{
+		Format:       in.Format(),
+		Magic:        h.Magic,
+		Version:      h.Version,
+		Size:         in.br.size,
+		Documents:    h.ndocuments,
+		Attrs:        h.ninvattrs,
+		Words:        h.ninvwords,
+		TotalWordLen: h.Total_word_len,
+		MetaSize:     h.meta_sz,
+	}
+}
+
+// RawMeta returns the meta data of the blob as it is stored in it.
+func (in Index) RawMeta() []byte {
+	r, err := in.br.get_meta()
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// ForeachAttr calls f for every attribute in the blob sorted by key.
+func (in Index) ForeachAttr(f func(key string, docs []IbDoc)) {
+	if in.m != nil {
+		for i := range in.m.invattrs {
+			docs, err := in.br.get_attr_docs(&in.m.invattrs[i])
+			if err == nil {
+				f(string(in.m.attr_name(i)), docs)
+			}
+		}
+		return
+	}
+	keys := make([]string, 0, len(in.Attrs))
+	for k := range in.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f(k, in.Attrs[k])
+	}
+}
+
+// ForeachWord calls f for every word in the blob sorted by word.
+func (in Index) ForeachWord(f func(w string, word Word)) {
+	if in.m != nil {
+		for i := range in.m.invwords {
+			w, err1 := in.br.get_word(&in.m.invwords[i])
+			docs, err2 := in.br.get_word_docs(&in.m.invwords[i])
+			if err1 == nil && err2 == nil {
+				f(w, Word{Docs: docs, inv: in.m.invwords[i], end: in.br.word_positions_end(in.m.invwords, i), br: in.br})
+			}
+		}
+		return
+	}
+	words := make([]string, 0, len(in.Words))
+	for w := range in.Words {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	for _, w := range words {
+		f(w, in.Words[w])
+	}
+}
@@ -5,20 +5,316 @@ package index_test
 
 import (
 	"bsearch/index"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"unsafe"
 )
 
 func TestOpen(t *testing.T) {
-	in, err := index.Open("/Users/art/db.blob")
+	w := index.NewWriter([]string{"id", "title"})
+	w.AddDocument(index.IbDoc{Order: 1, Id: 1}, []string{"1", "red bicycle"}, []string{"category:1000"}, []string{"red", "bicycle"})
+	w.AddDocument(index.IbDoc{Order: 3, Id: 3}, []string{"3", "red car"}, []string{"category:2000", "region:11"}, []string{"red", "car"})
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	in, err := index.Open(name)
 	if err != nil {
-		t.Fatalf("bindex.Open: %v\n", err)
+		t.Fatalf("bindex.Open: %v", err)
 	}
 	defer in.Close()
-	t.Log("%v\n", string(in.Docs[3]))
+	t.Logf("%v", string(in.Docs[3]))
 
 	for k, v := range in.Attrs {
-		t.Logf("%v -> %v\n", k, v)
+		t.Logf("%v -> %v", k, v)
+	}
+
+	st := in.Stat()
+	if st.Documents != 2 || st.Attrs != 3 || st.Words != 3 || st.Version != index.IbVersion {
+		t.Errorf("unexpected stat: %+v", st)
+	}
+	if in.Header() != "id\ttitle\n" {
+		t.Errorf("unexpected header: %q", in.Header())
+	}
+	var keys []string
+	in.ForeachAttr(func(key string, docs []index.IbDoc) {
+		keys = append(keys, key)
+	})
+	if len(keys) != 3 || keys[0] != "category:1000" || keys[2] != "region:11" {
+		t.Errorf("unexpected attrs: %v", keys)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	w := index.NewWriter([]string{"id", "title"})
+	w.AddDocument(index.IbDoc{Order: 1, Id: 1}, []string{"1", "red bicycle"}, []string{"category:1000"}, []string{"red", "bicycle"})
+	w.AddDocument(index.IbDoc{Order: 3, Id: 3}, []string{"3", "red car"}, []string{"category:1000"}, []string{"red", "car"})
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	in, err := index.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := in.Verify(0); errs != nil {
+		t.Errorf("Verify: %v", errs)
 	}
+	in.Close()
 
-	t.FailNow()
+	// Change the id of the first document, the postings of it now
+	// point to a document that doesn't exist.
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := int(unsafe.Sizeof(index.IbHeader{}))
+	binary.LittleEndian.PutUint32(b[docs:], 2)
+	if err := os.WriteFile(name, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	in, err = index.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	errs := in.Verify(0)
+	if len(errs) != 3 {
+		t.Fatalf("Verify: %v", errs)
+	}
+	for _, e := range errs {
+		if ve, ok := e.(*index.VerifyError); !ok || ve.Problem != "document 1 doesn't exist" {
+			t.Errorf("unexpected error: %v", e)
+		}
+	}
+}
+
+func TestOpenMappedUnsorted(t *testing.T) {
+	w := index.NewWriter([]string{"id"})
+	w.AddDocument(index.IbDoc{Order: 1, Id: 1}, []string{"1"}, []string{"category:1000"}, nil)
+	w.AddDocument(index.IbDoc{Order: 2, Id: 2}, []string{"2"}, []string{"category:1000"}, nil)
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Swap the two documents.
+	docs := int(unsafe.Sizeof(index.IbHeader{}))
+	sz := int(unsafe.Sizeof(index.IbDocument{}))
+	first := append([]byte(nil), b[docs:docs+sz]...)
+	copy(b[docs:], b[docs+sz:docs+2*sz])
+	copy(b[docs+sz:], first)
+	if err := os.WriteFile(name, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Our blobs are trusted to be sorted, platform blobs are checked.
+	in, err := index.OpenMapped(name)
+	if err != nil {
+		t.Fatalf("OpenMapped: %v", err)
+	}
+	in.Close()
+	binary.LittleEndian.PutUint64(b[0:], 0)
+	if err := os.WriteFile(name, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = index.OpenMapped(name)
+	var be *index.BlobError
+	if !errors.Is(err, index.ErrUnsorted) || !errors.As(err, &be) || be.Section != "documents" {
+		t.Errorf("OpenMapped: %v, expected unsorted documents", err)
+	}
+	in, err = index.Open(name)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer in.Close()
+	if d, _ := in.Doc(2); string(d) != "2" {
+		t.Errorf("Doc(2) = %q", d)
+	}
+
+	// Nothing wrong with it other than that.
+	errs := in.Verify(0)
+	if len(errs) != 1 {
+		t.Fatalf("Verify: %v", errs)
+	}
+	if ve, ok := errs[0].(*index.VerifyError); !ok || !ve.Warning || ve.Section != "documents" {
+		t.Errorf("Verify: %v, expected a warning about the documents", errs[0])
+	}
+}
+
+func TestWordPositionsUnterminated(t *testing.T) {
+	w := index.NewWriter([]string{"id"})
+	w.AddDocument(index.IbDoc{Order: 1, Id: 1}, []string{"1"}, nil, []string{"a", "b"})
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clear the flag on the last position of "a", the first word. The
+	// positions of "b" come after it in the blob.
+	invwords := int(unsafe.Sizeof(index.IbHeader{})) + int(unsafe.Sizeof(index.IbDocument{}))
+	posoff := binary.LittleEndian.Uint64(b[invwords+int(unsafe.Offsetof(index.IbInvword{}.Docops_offs)):])
+	flags := binary.LittleEndian.Uint16(b[posoff:])
+	binary.LittleEndian.PutUint16(b[posoff:], flags&^index.IbDocposLast)
+	if err := os.WriteFile(name, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, open := range []func(string) (*index.Index, error){index.Open, index.OpenMapped} {
+		in, err := open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, _ := in.Word("a")
+		if pos := a.Positions(&a.Docs[0]); pos != nil {
+			t.Errorf("positions of a: %v", pos)
+		}
+		bw, _ := in.Word("b")
+		if pos := bw.Positions(&bw.Docs[0]); len(pos) != 1 || pos[0].Pos != 1 {
+			t.Errorf("positions of b: %v", pos)
+		}
+		errs := in.Verify(0)
+		if len(errs) != 1 {
+			t.Errorf("Verify: %v", errs)
+		} else if ve, ok := errs[0].(*index.VerifyError); !ok || ve.Key != "a" {
+			t.Errorf("Verify: %v", errs[0])
+		}
+		in.Close()
+	}
+}
+
+func TestForeachWordPositions(t *testing.T) {
+	w := index.NewWriter([]string{"id"})
+	w.AddDocument(index.IbDoc{Order: 1, Id: 1}, []string{"1"}, nil, []string{"red", "car", "red"})
+	w.AddDocument(index.IbDoc{Order: 2, Id: 2}, []string{"2"}, nil, []string{"car"})
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var res []string
+	for _, open := range []func(string) (*index.Index, error){index.Open, index.OpenMapped} {
+		in, err := open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := ""
+		in.ForeachWord(func(w string, word index.Word) {
+			for i := range word.Docs {
+				r += fmt.Sprintf("%s/%d:", w, word.Docs[i].Doc.Id)
+				for _, p := range word.Positions(&word.Docs[i]) {
+					r += fmt.Sprintf(" %d", p.Pos)
+				}
+				r += "\n"
+			}
+		})
+		in.Close()
+		res = append(res, r)
+	}
+	if res[0] != "car/2: 0\ncar/1: 1\nred/1: 0 2\n" {
+		t.Errorf("Open: %q", res[0])
+	}
+	if res[1] != res[0] {
+		t.Errorf("OpenMapped: %q, Open: %q", res[1], res[0])
+	}
+}
+
+func TestOverlayBatch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := index.NewWriter([]string{"id"}).WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	in, err := index.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	in.Overlay().AddDocument(index.IbDoc{Id: 1, Order: 1}, []string{"1"}, []string{"a:a"}, []string{"w"})
+	before := in.Snapshot()
+
+	var b index.Batch
+	for id := uint32(2); id <= 3000; id++ {
+		b.AddDocument(index.IbDoc{Id: id, Order: id}, []string{"1"}, []string{"a:a"}, []string{"w"})
+	}
+	// Replace and delete documents added earlier in the same batch.
+	b.AddDocument(index.IbDoc{Id: 10, Order: 5000}, []string{"10"}, []string{"b:b"}, []string{"w"})
+	b.Delete(20)
+	b.Delete(1)
+	in.Overlay().Apply(&b)
+
+	if a := before.OverlayAttr("a:a"); len(a) != 1 {
+		t.Errorf("snapshot changed: %v", a)
+	}
+	a := in.OverlayAttr("a:a")
+	if len(a) != 2997 {
+		t.Errorf("a:a has %d documents", len(a))
+	}
+	for i := range a {
+		if a[i].Id == 1 || a[i].Id == 10 || a[i].Id == 20 || (i > 0 && !a[i].Less(a[i-1])) {
+			t.Fatalf("a:a: %v at %d", a[i], i)
+		}
+	}
+	w, _ := in.OverlayWord("w")
+	if len(w.Docs) != 2998 || w.Docs[0].Doc.Id != 10 {
+		t.Errorf("w has %d documents, first %v", len(w.Docs), w.Docs[0].Doc)
+	}
+	if bb := in.OverlayAttr("b:b"); len(bb) != 1 || bb[0].Id != 10 {
+		t.Errorf("b:b: %v", bb)
+	}
+	if !in.Deleted(1) || !in.Deleted(20) {
+		t.Errorf("1 or 20 not deleted")
+	}
+}
+
+func TestOverlaySnapshots(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := index.NewWriter([]string{"id"}).WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	in, err := index.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	// One update at a time, every one shares most of its state with
+	// the one before it.
+	var snaps []*index.Index
+	for id := uint32(1); id <= 2000; id++ {
+		in.Overlay().AddDocument(index.IbDoc{Id: id, Order: id}, []string{fmt.Sprint(id)}, []string{fmt.Sprint("price:", id)}, []string{"w"})
+		if id%500 == 0 {
+			in.Overlay().Delete(id - 1)
+			snaps = append(snaps, in.Snapshot())
+		}
+	}
+	for i, s := range snaps {
+		last := uint32(i+1) * 500
+		if d, ok := s.Doc(last); !ok || string(d) != fmt.Sprint(last) {
+			t.Errorf("snapshot %d: Doc(%d) = %q", i, last, d)
+		}
+		if _, ok := s.Doc(last + 1); ok {
+			t.Errorf("snapshot %d: has the later document %d", i, last+1)
+		}
+		if _, ok := s.Doc(last - 1); ok || !s.Deleted(last-1) {
+			t.Errorf("snapshot %d: %d not deleted", i, last-1)
+		}
+		w, _ := s.OverlayWord("w")
+		if len(w.Docs) != int(last)-i-1 {
+			t.Errorf("snapshot %d: w has %d documents", i, len(w.Docs))
+		}
+		if r := s.AttrRange("price", 1, 10000); len(r) != int(last)-i-1 {
+			t.Errorf("snapshot %d: %d prices", i, len(r))
+		}
+	}
 }
@@ -0,0 +1,201 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// VerifyError is a problem in the blob found by Verify. Warnings are
+// about blobs that work, but only with Open.
+type VerifyError struct {
+	Section string
+	Key     string
+	Problem string
+	Warning bool
+}
+
+func (e *VerifyError) Error() string {
+	w := ""
+	if e.Warning {
+		w = "warning: "
+	}
+	if e.Key == "" {
+		return w + e.Section + ": " + e.Problem
+	}
+	return w + e.Section + " " + e.Key + ": " + e.Problem
+}
+
+type verifier struct {
+	errs []error
+	n    int
+	max  int
+	ids  []uint32
+}
+
+func (v *verifier) add(section, key, f string, a ...interface{}) bool {
+	v.errs = append(v.errs, &VerifyError{section, key, fmt.Sprintf(f, a...), false})
+	v.n++
+	return v.max > 0 && v.n >= v.max
+}
+
+// unsorted warns that the section isn't in the order OpenMapped needs.
+func (v *verifier) unsorted(section, at string) {
+	v.errs = append(v.errs, &VerifyError{section, "", "not sorted at " + at + ", can't be opened mapped", true})
+}
+
+func (v *verifier) exists(id uint32) bool {
+	i := sort.Search(len(v.ids), func(i int) bool { return v.ids[i] >= id })
+	return i < len(v.ids) && v.ids[i] == id
+}
+
+// Verify checks the blob for the things the ops and lookups depend on
+// that are not checked when it's opened:
+//
+// The documents have data in the blob with as many fields as attr.order
+// in the meta data. The postings of attributes and words are sorted in
+// descending order without duplicates and all their documents exist.
+// If the documents aren't sorted by id or the attributes and words by
+// name, which only OpenMapped needs, that is a warning.
+//
+// Verify stops after max problems, not counting warnings, if max is
+// more than 0.
+func (in Index) Verify(max int) []error {
+	v := &verifier{max: max}
+	br := in.br
+
+	docs, err := br.get_documents()
+	if err != nil {
+		return []error{err}
+	}
+	nfields := 0
+	in.Meta.GetNode("attr", "order").ForeachSorted(func(k, val string) {
+		nfields++
+	})
+	sorted := true
+	v.ids = make([]uint32, len(docs))
+	for i := range docs {
+		d := &docs[i]
+		v.ids[i] = d.Doc.Id
+		if i > 0 && sorted && docs[i-1].Doc.Id >= d.Doc.Id {
+			sorted = false
+			v.unsorted("documents", fmt.Sprint("id ", d.Doc.Id))
+		}
+		data, err := br.get_document_data(d)
+		if err != nil {
+			if v.add("document", fmt.Sprint(d.Doc.Id), "%v", err) {
+				return v.errs
+			}
+			continue
+		}
+		if n := bytes.Count(data, []byte("\t")) + 1; nfields > 0 && n != nfields {
+			if v.add("document", fmt.Sprint(d.Doc.Id), "%v fields, attr.order has %v", n, nfields) {
+				return v.errs
+			}
+		}
+	}
+	if !sorted {
+		sort.Slice(v.ids, func(i, j int) bool { return v.ids[i] < v.ids[j] })
+	}
+	for i := 1; i < len(v.ids); i++ {
+		if v.ids[i] == v.ids[i-1] {
+			if v.add("documents", "", "duplicate id %v", v.ids[i]) {
+				return v.errs
+			}
+		}
+	}
+
+	invattrs, err := br.get_invattrs()
+	if err != nil {
+		return append(v.errs, err)
+	}
+	prev := ""
+	sorted = true
+	for i := range invattrs {
+		name, err := br.get_attr_name(&invattrs[i])
+		if err != nil {
+			if v.add("attr", fmt.Sprint(i), "%v", err) {
+				return v.errs
+			}
+			continue
+		}
+		if i > 0 && sorted && name <= prev {
+			sorted = false
+			v.unsorted("attrs", name)
+		}
+		prev = name
+		p, err := br.get_attr_docs(&invattrs[i])
+		if err != nil {
+			if v.add("attr", name, "%v", err) {
+				return v.errs
+			}
+			continue
+		}
+		for j := range p {
+			if v.posting("attr", name, p, j) {
+				return v.errs
+			}
+		}
+	}
+
+	invwords, err := br.get_invwords()
+	if err != nil {
+		return append(v.errs, err)
+	}
+	prev = ""
+	sorted = true
+	for i := range invwords {
+		w, err := br.get_word(&invwords[i])
+		if err != nil {
+			if v.add("word", fmt.Sprint(i), "%v", err) {
+				return v.errs
+			}
+			continue
+		}
+		if i > 0 && sorted && w <= prev {
+			sorted = false
+			v.unsorted("words", w)
+		}
+		prev = w
+		wd, err := br.get_word_docs(&invwords[i])
+		if err != nil {
+			if v.add("word", w, "%v", err) {
+				return v.errs
+			}
+			continue
+		}
+		end := br.word_positions_end(invwords, i)
+		p := make([]IbDoc, len(wd))
+		for j := range wd {
+			p[j] = wd[j].Doc
+		}
+		for j := range p {
+			if v.posting("word", w, p, j) {
+				return v.errs
+			}
+			if _, err := br.get_word_positions(&invwords[i], end, &wd[j]); err != nil {
+				if v.add("word", w, "document %v: %v", p[j].Id, err) {
+					return v.errs
+				}
+			}
+		}
+	}
+	return v.errs
+}
+
+// posting checks element j of the posting p.
+func (v *verifier) posting(section, key string, p []IbDoc, j int) bool {
+	if j > 0 && !p[j].Less(p[j-1]) {
+		if p[j].Equal(p[j-1]) {
+			return v.add(section, key, "duplicate document %v", p[j].Id)
+		}
+		return v.add(section, key, "not sorted at document %v", p[j].Id)
+	}
+	if !v.exists(p[j].Id) {
+		return v.add(section, key, "document %v doesn't exist", p[j].Id)
+	}
+	return false
+}
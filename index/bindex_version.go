@@ -0,0 +1,158 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package index
+
+import (
+	"fmt"
+	"sort"
+)
+
+/*
+ * Every version of the blob format has a decoder that fills in the
+ * Index from the blob. Open picks the decoder from the Magic and
+ * Version in the header so that the rest of the code never has to
+ * care about which version it's looking at.
+ *
+ * The platform blobs from 2.3 to 2.5 predate IbMagic. What they keep
+ * in Version isn't written down anywhere we have access to, so every
+ * blob without our magic is read as one of them unless the versions to
+ * accept are listed in Options.LegacyVersions (legacy_versions in the
+ * engine config). Blobs with other versions are rejected with a
+ * VersionError that tells what was found. The 2.6 format will need its
+ * own decoder here once we know what it looks like.
+ */
+
+type blob_format struct {
+	name   string
+	load   func(in *Index) error
+	mapped func(in *Index) error
+}
+
+// formats are the versions of the blobs with IbMagic that we can read.
+var formats = map[uint64]blob_format{
+	1: {"bsearch 1", load_v1, map_v1},
+}
+
+// legacy is the format of platform blobs.
+var legacy = blob_format{"platform 2.3-2.5", load_legacy, map_legacy}
+
+// VersionError is returned by Open for blobs of a version we can't read.
+// Legacy is set for blobs without IbMagic. Accepted are the versions
+// that can be read.
+type VersionError struct {
+	Version  uint64
+	Legacy   bool
+	Accepted []uint64
+}
+
+func (e *VersionError) Error() string {
+	if e.Legacy {
+		return fmt.Sprintf("index: not a bsearch blob and unknown platform blob version %d, accepted platform versions: %v (see legacy_versions)", e.Version, e.Accepted)
+	}
+	return fmt.Sprintf("index: unsupported blob version %d, supported versions: %v", e.Version, e.Accepted)
+}
+
+// blob_format_of returns the format of the blob. Platform blobs are
+// only checked against legacy_versions if there are any.
+func blob_format_of(hdr *IbHeader, legacy_versions []uint64) (*blob_format, error) {
+	if hdr.Magic != IbMagic {
+		if len(legacy_versions) == 0 {
+			return &legacy, nil
+		}
+		for _, v := range legacy_versions {
+			if v == hdr.Version {
+				return &legacy, nil
+			}
+		}
+		return nil, &VersionError{hdr.Version, true, legacy_versions}
+	}
+	f, ok := formats[hdr.Version]
+	if !ok {
+		var known []uint64
+		for v := range formats {
+			known = append(known, v)
+		}
+		sort.Slice(known, func(i, j int) bool { return known[i] < known[j] })
+		return nil, &VersionError{hdr.Version, false, known}
+	}
+	return &f, nil
+}
+
+// load_legacy decodes platform 2.3-2.5 blobs. As far as we know their
+// layout is the same as version 1, which was copied from it.
+func load_legacy(in *Index) error {
+	return load_v1(in)
+}
+
+// map_legacy is load_legacy for OpenMapped. Unlike ours, platform blobs
+// have to be checked for the order the lookups need.
+func map_legacy(in *Index) error {
+	if err := map_v1(in); err != nil {
+		return err
+	}
+	return in.m.check_sorted()
+}
+
+func load_v1(in *Index) error {
+	docs, err := in.br.get_documents()
+	if err != nil {
+		return err
+	}
+	in.Docs = make(map[uint32][]byte)
+	for _, d := range docs {
+		in.Docs[d.Doc.Id], err = in.br.get_document_data(&d)
+		if err != nil {
+			return err
+		}
+	}
+
+	invattrs, err := in.br.get_invattrs()
+	if err != nil {
+		return err
+	}
+	in.Attrs = make(map[string][]IbDoc)
+	for _, a := range invattrs {
+		name, err := in.br.get_attr_name(&a)
+		if err != nil {
+			return err
+		}
+		in.Attrs[name], err = in.br.get_attr_docs(&a)
+		if err != nil {
+			return err
+		}
+	}
+
+	invwords, err := in.br.get_invwords()
+	if err != nil {
+		return err
+	}
+	in.Words = make(map[string]Word)
+	for i, w := range invwords {
+		word, err := in.br.get_word(&w)
+		if err != nil {
+			return err
+		}
+		docs, err := in.br.get_word_docs(&w)
+		if err != nil {
+			return err
+		}
+		in.Words[word] = Word{Docs: docs, inv: w, end: in.br.word_positions_end(invwords, i), br: in.br}
+	}
+
+	return load_meta(in)
+}
+
+func load_meta(in *Index) error {
+	meta, err := in.br.get_meta()
+	if err != nil {
+		return err
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	if err := in.Meta.LoadJson(meta); err != nil {
+		return &BlobError{"meta", ErrCorrupt}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package index
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FieldGap is how many positions Writer users leave between the words of
+// two fields of a document, so that phrases don't match across fields.
+const FieldGap = 100
+
+// SplitWords splits text into lower case words, everything that isn't a
+// letter or a number separates words. This is what bsearch-index
+// indexes and what queries look for.
+func SplitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Word is the inverted index of one word.
+// Docs - the documents containing the word, sorted like the attribute postings.
+type Word struct {
+	Docs []IbDocindex
+	inv  IbInvword
+	end  uint64
+	br   *blob_reader
+	// Positions of the words in the overlay, Posptr is the index.
+	pos [][]IbDocpos
+}
+
+// Positions returns the positions of the word in one of the documents from Docs.
+// The positions are only found when they're needed, broken positions in
+// the blob are treated as no positions at all. Verify reports them.
+func (w Word) Positions(di *IbDocindex) []IbDocpos {
+	if w.br == nil {
+		return w.pos[di.Posptr]
+	}
+	pos, err := w.br.get_word_positions(&w.inv, w.end, di)
+	if err != nil {
+		return nil
+	}
+	return pos
+}
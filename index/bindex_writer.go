@@ -0,0 +1,236 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// Writer builds an index blob from documents.
+type Writer struct {
+	fields []string
+	docs   []wdoc
+	attrs  map[string][]IbDoc
+	words  map[string][]wposting
+}
+
+type wdoc struct {
+	doc  IbDoc
+	data string
+}
+
+type wposting struct {
+	doc IbDoc
+	pos []uint16
+}
+
+// NewWriter returns a Writer for documents with the given fields. The
+// field names end up in attr.order in the meta data.
+func NewWriter(fields []string) *Writer {
+	return &Writer{
+		fields: fields,
+		attrs:  make(map[string][]IbDoc),
+		words:  make(map[string][]wposting),
+	}
+}
+
+// AddDocument adds a document to the index.
+// data - the values of the fields of the document.
+// attrs - the attributes of the document in the form "name:value".
+// words - the words of the document in the order they appear in it.
+// Empty words aren't indexed but take up a position, they are used for
+// the FieldGap between fields.
+func (w *Writer) AddDocument(doc IbDoc, data []string, attrs []string, words []string) {
+	w.docs = append(w.docs, wdoc{doc, strings.Join(data, "\t")})
+	for _, a := range attrs {
+		p := w.attrs[a]
+		if len(p) > 0 && p[len(p)-1] == doc {
+			continue
+		}
+		w.attrs[a] = append(p, doc)
+	}
+	for i, wd := range words {
+		if i > 0xffff {
+			break
+		}
+		if wd == "" {
+			continue
+		}
+		p := w.words[wd]
+		if len(p) == 0 || p[len(p)-1].doc != doc {
+			p = append(p, wposting{doc: doc})
+		}
+		p[len(p)-1].pos = append(p[len(p)-1].pos, uint16(i))
+		w.words[wd] = p
+	}
+}
+
+// descending sorts documents in the order they are in postings.
+type descending []IbDoc
+
+func (d descending) Len() int           { return len(d) }
+func (d descending) Less(i, j int) bool { return d[j].Less(d[i]) }
+func (d descending) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+type wpostings []wposting
+
+func (p wpostings) Len() int           { return len(p) }
+func (p wpostings) Less(i, j int) bool { return p[j].doc.Less(p[i].doc) }
+func (p wpostings) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+type wdocs []wdoc
+
+func (d wdocs) Len() int           { return len(d) }
+func (d wdocs) Less(i, j int) bool { return d[i].doc.Id < d[j].doc.Id }
+func (d wdocs) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+// raw returns the memory of sz bytes at p. The blob is the in-memory
+// representation of the Ib structs, the same way the reader maps it.
+func raw(p unsafe.Pointer, sz uintptr) []byte {
+	return unsafe.Slice((*byte)(p), sz)
+}
+
+// blob_data is the part of the blob after the fixed size arrays.
+type blob_data struct {
+	buf  bytes.Buffer
+	base uint64
+}
+
+func (bd *blob_data) align(a int) {
+	for bd.buf.Len()%a != 0 {
+		bd.buf.WriteByte(0)
+	}
+}
+
+// add appends b aligned to a and returns its offset in the blob.
+func (bd *blob_data) add(b []byte, a int) uint64 {
+	bd.align(a)
+	off := bd.base + uint64(bd.buf.Len())
+	bd.buf.Write(b)
+	return off
+}
+
+func (bd *blob_data) cstring(s string) uint64 {
+	return bd.add(append([]byte(s), 0), 1)
+}
+
+// Write writes the index blob to out.
+func (w *Writer) Write(out io.Writer) error {
+	var hdr IbHeader
+
+	sort.Sort(wdocs(w.docs))
+	for i := 1; i < len(w.docs); i++ {
+		if w.docs[i].doc.Id == w.docs[i-1].doc.Id {
+			return fmt.Errorf("duplicate document id %v", w.docs[i].doc.Id)
+		}
+	}
+	attrnames := make([]string, 0, len(w.attrs))
+	for a := range w.attrs {
+		attrnames = append(attrnames, a)
+	}
+	sort.Strings(attrnames)
+	wordnames := make([]string, 0, len(w.words))
+	for wd := range w.words {
+		wordnames = append(wordnames, wd)
+	}
+	sort.Strings(wordnames)
+
+	documents := make([]IbDocument, len(w.docs))
+	invattrs := make([]IbInvattr, len(attrnames))
+	invwords := make([]IbInvword, len(wordnames))
+
+	hdr.Magic = IbMagic
+	hdr.Version = IbVersion
+	hdr.ndocuments = uint64(len(documents))
+	hdr.documents_off = uint64(unsafe.Sizeof(hdr))
+	hdr.ninvattrs = uint64(len(invattrs))
+	hdr.invattrs_off = hdr.documents_off + hdr.ndocuments*uint64(unsafe.Sizeof(IbDocument{}))
+	hdr.ninvwords = uint64(len(invwords))
+	hdr.invwords_off = hdr.invattrs_off + hdr.ninvattrs*uint64(unsafe.Sizeof(IbInvattr{}))
+
+	bd := blob_data{base: hdr.invwords_off + hdr.ninvwords*uint64(unsafe.Sizeof(IbInvword{}))}
+
+	for i, d := range w.docs {
+		documents[i].Doc = d.doc
+		documents[i].Doclen = uint32(len(d.data) + 1)
+		documents[i].Blob_offs = bd.cstring(d.data)
+	}
+
+	for i, a := range attrnames {
+		p := w.attrs[a]
+		sort.Sort(descending(p))
+		invattrs[i].Attr_offs = bd.cstring(a)
+		invattrs[i].Docslen = uint64(len(p)) * uint64(unsafe.Sizeof(IbDoc{}))
+		invattrs[i].Docs_offs = bd.add(raw(unsafe.Pointer(&p[0]), uintptr(invattrs[i].Docslen)), 8)
+	}
+
+	for i, wd := range wordnames {
+		p := w.words[wd]
+		sort.Sort(wpostings(p))
+		docs := make([]IbDocindex, len(p))
+		var pos []IbDocpos
+		for j := range p {
+			docs[j].Doc = p[j].doc
+			docs[j].Posptr = uint32(len(pos))
+			for _, ps := range p[j].pos {
+				pos = append(pos, IbDocpos{Pos: ps})
+			}
+			pos[len(pos)-1].Flags |= IbDocposLast
+		}
+		invwords[i].Word_offs = bd.cstring(wd)
+		hdr.Total_word_len += uint64(len(wd))
+		invwords[i].Docslen = uint64(len(docs)) * uint64(unsafe.Sizeof(IbDocindex{}))
+		invwords[i].Docs_offs = bd.add(raw(unsafe.Pointer(&docs[0]), uintptr(invwords[i].Docslen)), 8)
+		invwords[i].Docops_offs = bd.add(raw(unsafe.Pointer(&pos[0]), uintptr(len(pos))*unsafe.Sizeof(IbDocpos{})), 8)
+	}
+
+	order := make(map[string]string)
+	for i, f := range w.fields {
+		order[fmt.Sprint(i)] = f
+	}
+	meta, err := json.Marshal(map[string]interface{}{"attr": map[string]interface{}{"order": order}})
+	if err != nil {
+		return err
+	}
+	hdr.meta_sz = uint64(len(meta))
+	hdr.meta_off = bd.add(meta, 8)
+
+	parts := [][]byte{raw(unsafe.Pointer(&hdr), unsafe.Sizeof(hdr))}
+	if len(documents) > 0 {
+		parts = append(parts, raw(unsafe.Pointer(&documents[0]), uintptr(len(documents))*unsafe.Sizeof(IbDocument{})))
+	}
+	if len(invattrs) > 0 {
+		parts = append(parts, raw(unsafe.Pointer(&invattrs[0]), uintptr(len(invattrs))*unsafe.Sizeof(IbInvattr{})))
+	}
+	if len(invwords) > 0 {
+		parts = append(parts, raw(unsafe.Pointer(&invwords[0]), uintptr(len(invwords))*unsafe.Sizeof(IbInvword{})))
+	}
+	parts = append(parts, bd.buf.Bytes())
+	for _, b := range parts {
+		if _, err := out.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the index blob to the file name.
+func (w *Writer) WriteFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,130 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package index_test
+
+import (
+	"bsearch/index"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriterRoundtrip(t *testing.T) {
+	w := index.NewWriter([]string{"id", "title"})
+	w.AddDocument(index.IbDoc{Order: 1, Id: 1}, []string{"1", "red bicycle"}, []string{"category:1000"}, []string{"red", "bicycle"})
+	w.AddDocument(index.IbDoc{Order: 3, Id: 3}, []string{"3", "red car red"}, []string{"category:2000"}, []string{"red", "car", "red"})
+	w.AddDocument(index.IbDoc{Order: 2, Id: 2}, []string{"2", "blue bicycle"}, []string{"category:1000"}, []string{"blue", "bicycle"})
+
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	for _, open := range []func(string) (*index.Index, error){index.Open, index.OpenMapped} {
+		in, err := open(name)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		checkRoundtrip(t, in)
+		in.Close()
+	}
+}
+
+func checkRoundtrip(t *testing.T, in *index.Index) {
+	if d, _ := in.Doc(3); string(d) != "3\tred car red" {
+		t.Errorf("unexpected doc 3: %q", d)
+	}
+	if _, exists := in.Doc(4); exists {
+		t.Errorf("doc 4 exists")
+	}
+	if c := in.Attr("category:1000"); len(c) != 2 || c[0].Id != 2 || c[1].Id != 1 {
+		t.Errorf("unexpected category:1000: %v", c)
+	}
+	if c := in.Attr("category:1500"); c != nil {
+		t.Errorf("unexpected category:1500: %v", c)
+	}
+	if r := in.AttrRange("category", 1000, 1999); len(r) != 1 || r[0] != "category:1000" {
+		t.Errorf("unexpected range: %v", r)
+	}
+	if in.Field("title") != 1 {
+		t.Errorf("title field %d, expected 1", in.Field("title"))
+	}
+	red, ok := in.Word("red")
+	if !ok || len(red.Docs) != 2 {
+		t.Fatalf("unexpected red: %v", red.Docs)
+	}
+	if red.Docs[0].Doc.Id != 3 {
+		t.Fatalf("red first doc %d, expected 3", red.Docs[0].Doc.Id)
+	}
+	pos := red.Positions(&red.Docs[0])
+	if len(pos) != 2 || pos[0].Pos != 0 || pos[1].Pos != 2 {
+		t.Errorf("unexpected positions: %v", pos)
+	}
+}
+
+func TestOpenUnknownVersion(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := index.NewWriter([]string{"id"}).WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint64(b[8:], 4711)
+	if err := os.WriteFile(name, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = index.Open(name)
+	var ve *index.VersionError
+	if !errors.As(err, &ve) || ve.Version != 4711 || ve.Legacy {
+		t.Errorf("Open: %v, expected a version error", err)
+	}
+
+	// Without our magic it's a platform blob, accepted unless the
+	// versions to accept are given and its version isn't one of them.
+	binary.LittleEndian.PutUint64(b[0:], 0)
+	binary.LittleEndian.PutUint64(b[8:], 4712)
+	if err := os.WriteFile(name, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	in, err := index.Open(name)
+	if err != nil {
+		t.Fatalf("Open legacy: %v", err)
+	}
+	in.Close()
+	_, err = index.OpenWith(name, index.Options{LegacyVersions: []uint64{4711}})
+	if !errors.As(err, &ve) || ve.Version != 4712 || !ve.Legacy {
+		t.Errorf("Open unlisted legacy: %v, expected a version error", err)
+	}
+	in, err = index.OpenWith(name, index.Options{Mapped: true, LegacyVersions: []uint64{4711, 4712}})
+	if err != nil {
+		t.Fatalf("Open listed legacy: %v", err)
+	}
+	in.Close()
+}
+
+func TestOpenTruncated(t *testing.T) {
+	w := index.NewWriter([]string{"id", "title"})
+	w.AddDocument(index.IbDoc{Order: 1, Id: 1}, []string{"1", "red bicycle"}, []string{"category:1000"}, []string{"red", "bicycle"})
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sz := range []int64{fi.Size() - 1, fi.Size() / 2, 40, 0} {
+		if err := os.Truncate(name, sz); err != nil {
+			t.Fatal(err)
+		}
+		_, err := index.Open(name)
+		var be *index.BlobError
+		if !errors.Is(err, index.ErrTruncated) || !errors.As(err, &be) {
+			t.Errorf("Open truncated to %d: %v, expected truncated", sz, err)
+		}
+	}
+}
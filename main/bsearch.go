@@ -27,6 +27,10 @@ func (h headers) Add(k, v string) {
 	h[k] = v
 }
 
+func (h headers) AddSub(k, sk, v string) {
+	h[k+":"+sk] = v
+}
+
 func stdtest(in *index.Index) {
 	p := parser.Parse(in, "0 lim:10 count_all(hej) root:10 OR magic:boll status:active")
 	q := p.Stack[0]
@@ -41,7 +45,8 @@ func stdtest(in *index.Index) {
 		if d == nil {
 			break
 		}
-		fmt.Printf("%v\n", string(in.Docs[d.Id]))
+		doc, _ := in.Doc(d.Id)
+		fmt.Printf("%v\n", string(doc))
 		*s = *d
 		s.Inc()
 	}
@@ -12,11 +12,18 @@ type attr []index.IbDoc
 
 // QueryOp that is the set of all documents for one attribute.
 func NewAttr(in *index.Index, key string) QueryOp {
-	a := attr(in.Attrs[key])
-	return &a
+	a := attr(in.Attr(key))
+	if !in.HasOverlay() {
+		return &a
+	}
+	oa := attr(in.OverlayAttr(key))
+	return newOverlay(in, &a, &oa)
 }
 
 func (ba attr) CurrentDoc() *index.IbDoc {
+	if len(ba) == 0 {
+		return nil
+	}
 	return &ba[0]
 }
 
@@ -68,7 +75,7 @@ func (ba *attr) NextDoc(search *index.IbDoc) *index.IbDoc {
 	/* End of inline expanded sort.Search */
 
 	if i == l {
-		ba = nil
+		(*ba) = (*ba)[l:]
 		return nil
 	}
 	(*ba) = (*ba)[i:]
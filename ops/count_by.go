@@ -0,0 +1,52 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+	"fmt"
+	"log"
+)
+
+type count_by struct {
+	in     *index.Index
+	col    int
+	counts map[string]uint
+	next   QueryOp
+	name   string
+}
+
+// CountBy counts the documents that pass through it for each value of
+// the attribute attr. The counts are returned as subkeys of the header name.
+func CountBy(in *index.Index, name, attr string) QueryContainer {
+	return &count_by{in: in, col: in.Field(attr), counts: make(map[string]uint), name: name}
+}
+
+func (cb *count_by) Add(n ...QueryOp) {
+	if cb.next != nil || len(n) != 1 {
+		log.Fatal("count_by.Add multiple")
+	}
+	cb.next = n[0]
+}
+
+func (cb count_by) CurrentDoc() *index.IbDoc {
+	return cb.next.CurrentDoc()
+}
+
+func (cb *count_by) NextDoc(s *index.IbDoc) *index.IbDoc {
+	d := cb.next.NextDoc(s)
+	if d != nil && cb.col != -1 {
+		if v := cb.in.DocField(d.Id, cb.col); v != "" {
+			cb.counts[v]++
+		}
+	}
+	return d
+}
+
+func (cb count_by) ProcessHeaders(hc HeaderCollector) {
+	for v, c := range cb.counts {
+		hc.AddSub(cb.name, v, fmt.Sprint(c))
+	}
+	cb.next.ProcessHeaders(hc)
+}
@@ -0,0 +1,52 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops_test
+
+import (
+	"bsearch/index"
+	"bsearch/ops"
+	"bsearch/parser"
+	"bsearch/parser/opers"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// subHeaders collects the headers as "key:subkey=value".
+type subHeaders []string
+
+func (h *subHeaders) Add(k, v string) {
+	*h = append(*h, k+"="+v)
+}
+
+func (h *subHeaders) AddSub(k, sk, v string) {
+	*h = append(*h, k+":"+sk+"="+v)
+}
+
+func TestCountBy(t *testing.T) {
+	in := testIndex(t, []string{"id", "category"}, func(w *index.Writer) {
+		for i, c := range []string{"1000", "2000", "1000", "", "1000"} {
+			id := uint32(i + 1)
+			w.AddDocument(index.IbDoc{Id: id, Order: id}, []string{fmt.Sprint(id), c}, []string{"a:a"}, nil)
+		}
+	})
+
+	cb := ops.CountBy(in, "cat", "category")
+	cb.Add(ops.NewAttr(in, "a:a"))
+	if r := ids(cb); r != "[5 4 3 2 1]" {
+		t.Errorf("count_by changed the result: %v", r)
+	}
+	var h subHeaders
+	cb.ProcessHeaders(&h)
+	sort.Strings(h)
+	if r := strings.Join(h, " "); r != "cat:1000=3 cat:2000=1" {
+		t.Errorf("headers: %v", r)
+	}
+
+	o, _ := parser.ParseClassic("count_by(cat, nope) a:a")
+	if _, errs := o.Generate(in); len(errs) != 1 || errs[0] != opers.ErrCountField {
+		t.Errorf("count_by on unknown field: %v", errs)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+	"context"
+)
+
+// The context is checked every deadlineCheck calls to NextDoc.
+const deadlineCheck = 64
+
+// Deadline stops a query when its context is done. The ops that read
+// the index are wrapped with Wrap and return no more documents after
+// that, so every op above them finishes and the query ends with the
+// documents found so far. Ops that loop over other ops on their own,
+// like phrase, sort and rand, get the deadline with Attach and stop
+// their loops too.
+//
+// A Deadline is used by one query on one goroutine.
+type Deadline struct {
+	ctx     context.Context
+	calls   uint
+	expired bool
+}
+
+func NewDeadline(ctx context.Context) *Deadline {
+	return &Deadline{ctx: ctx}
+}
+
+// Expired tells if the query was stopped. The document returned by the
+// NextDoc call that stopped it can be wrong, an exclusion might not
+// have seen the document it should exclude.
+func (dl *Deadline) Expired() bool {
+	return dl != nil && dl.expired
+}
+
+func (dl *Deadline) check() bool {
+	if !dl.expired && dl.calls%deadlineCheck == 0 && dl.ctx.Err() != nil {
+		dl.expired = true
+	}
+	dl.calls++
+	return dl.expired
+}
+
+// stop is check for ops that might not have a deadline.
+func (dl *Deadline) stop() bool {
+	return dl != nil && dl.check()
+}
+
+// looper is an op with a loop of its own that has to stop on the deadline.
+type looper interface {
+	setDeadline(dl *Deadline)
+}
+
+// Attach gives the deadline to q if it loops on its own.
+func (dl *Deadline) Attach(q QueryOp) {
+	if l, ok := q.(looper); ok && dl != nil {
+		l.setDeadline(dl)
+	}
+}
+
+// Wrap returns q stopped by the deadline. Wrap on a nil Deadline
+// returns q.
+func (dl *Deadline) Wrap(q QueryOp) QueryOp {
+	if dl == nil {
+		return q
+	}
+	dl.Attach(q)
+	return &deadlined{dl: dl, next: q}
+}
+
+type deadlined struct {
+	dl   *Deadline
+	next QueryOp
+	done bool
+}
+
+func (d *deadlined) CurrentDoc() *index.IbDoc {
+	if d.done {
+		return nil
+	}
+	return d.next.CurrentDoc()
+}
+
+func (d *deadlined) NextDoc(s *index.IbDoc) *index.IbDoc {
+	if d.done || d.dl.check() {
+		d.done = true
+		return nil
+	}
+	return d.next.NextDoc(s)
+}
+
+func (d *deadlined) ProcessHeaders(hc HeaderCollector) {
+	d.next.ProcessHeaders(hc)
+}
@@ -0,0 +1,59 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops_test
+
+import (
+	"bsearch/index"
+	"bsearch/ops"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// expiring is a context that is done after it has been checked n times.
+type expiring struct {
+	context.Context
+	n int
+}
+
+func (e *expiring) Err() error {
+	if e.n == 0 {
+		return context.DeadlineExceeded
+	}
+	e.n--
+	return nil
+}
+
+func TestDeadlinePhrase(t *testing.T) {
+	in := testIndex(t, []string{"id"}, func(w *index.Writer) {
+		for i := uint32(1); i <= 1000; i++ {
+			w.AddDocument(index.IbDoc{Id: i, Order: i}, []string{fmt.Sprint(i)}, nil, []string{"red", "big", "car"})
+		}
+	})
+
+	for _, mk := range []func(dl *ops.Deadline) ops.QueryOp{
+		func(dl *ops.Deadline) ops.QueryOp {
+			return dl.Wrap(ops.NewPhrase(in, "red", "car"))
+		},
+		func(dl *ops.Deadline) ops.QueryOp {
+			s := ops.NewSort(in, "id", false)
+			s.Add(dl.Wrap(ops.NewPhrase(in, "red", "big")))
+			dl.Attach(s)
+			return s
+		},
+	} {
+		// Expires after the first check, in the middle of the loops.
+		dl := ops.NewDeadline(&expiring{context.Background(), 1})
+		q := mk(dl)
+		n := 0
+		for d := q.NextDoc(index.NullDoc()); d != nil && !dl.Expired(); n++ {
+			s := *d
+			s.Inc()
+			d = q.NextDoc(&s)
+		}
+		if !dl.Expired() || n >= 1000 {
+			t.Errorf("query didn't stop: expired %v, %d docs", dl.Expired(), n)
+		}
+	}
+}
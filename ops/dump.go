@@ -33,5 +33,19 @@ func Dump(o interface{}, indent int) {
 		Dump(o.(*offset).next, indent+1)
 	case *count_all:
 		Dump(o.(*count_all).next, indent+1)
+	case *count_by:
+		Dump(o.(*count_by).next, indent+1)
+	case *exclusion:
+		Dump(o.(*exclusion).include, indent+1)
+		Dump(o.(*exclusion).exclude, indent+1)
+	case *random:
+		Dump(o.(*random).next, indent+1)
+	case *sorter:
+		Dump(o.(*sorter).next, indent+1)
+	case *phrase:
+		Dump(o.(*phrase).it, indent+1)
+	case *overlay:
+		Dump(o.(*overlay).base, indent+1)
+		Dump(o.(*overlay).added, indent+1)
 	}
 }
@@ -0,0 +1,59 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+)
+
+type exclusion struct {
+	include QueryOp
+	exclude QueryContainer
+	// The last document that wasn't excluded, only set once started.
+	cur     *index.IbDoc
+	started bool
+}
+
+// QueryOp that is the set of documents in include that are not in exclude.
+// More QueryOps can be added to the excluded set with Add.
+func NewExclusion(include, exclude QueryOp) QueryContainer {
+	return &exclusion{include: include, exclude: NewUnion(exclude)}
+}
+
+func (ex *exclusion) Add(n ...QueryOp) {
+	ex.exclude.Add(n...)
+}
+
+// CurrentDoc returns the last document that wasn't excluded, the
+// current document of include might be.
+func (ex *exclusion) CurrentDoc() *index.IbDoc {
+	if !ex.started {
+		return ex.NextDoc(index.NullDoc())
+	}
+	return ex.cur
+}
+
+func (ex *exclusion) NextDoc(search *index.IbDoc) *index.IbDoc {
+	ex.started = true
+	ex.cur = nil
+	s := *search
+	for {
+		d := ex.include.NextDoc(&s)
+		if d == nil {
+			return nil
+		}
+		e := ex.exclude.NextDoc(d)
+		if e == nil || !e.Equal(*d) {
+			ex.cur = d
+			return d
+		}
+		s = *d
+		s.Inc()
+	}
+}
+
+func (ex exclusion) ProcessHeaders(hc HeaderCollector) {
+	ex.include.ProcessHeaders(hc)
+	ex.exclude.ProcessHeaders(hc)
+}
@@ -0,0 +1,42 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops_test
+
+import (
+	"bsearch/ops"
+	"testing"
+)
+
+func TestExclusion(t *testing.T) {
+	in := wordIndex(t)
+
+	ex := ops.NewExclusion(ops.NewAttr(in, "category:1000"), ops.NewWord(in, "big"))
+	if r := ids(ex); r != "[5]" {
+		t.Errorf("category:1000 not big: %v", r)
+	}
+	ex = ops.NewExclusion(ops.NewWord(in, "car"), ops.NewAttr(in, "category:1000"))
+	ex.Add(ops.NewRange(in, "price", 501, 501))
+	if r := ids(ex); r != "[3]" {
+		t.Errorf("car not category:1000 not price:501: %v", r)
+	}
+}
+
+func TestExclusionInUnion(t *testing.T) {
+	in := wordIndex(t)
+
+	// The union looks at CurrentDoc before and between the NextDoc
+	// calls, that must not be an excluded document.
+	ex := ops.NewExclusion(ops.NewAttr(in, "category:1000"), ops.NewAttr(in, "category:1000"))
+	un := ops.NewUnion()
+	un.Add(ex, ops.NewAttr(in, "zzz:1"))
+	if r := ids(un); r != "[]" {
+		t.Errorf("category:1000 not category:1000 or zzz:1: %v", r)
+	}
+	ex = ops.NewExclusion(ops.NewWord(in, "red"), ops.NewWord(in, "big"))
+	un = ops.NewUnion()
+	un.Add(ex, ops.NewRange(in, "price", 250, 250))
+	if r := ids(un); r != "[5 4 3 2]" {
+		t.Errorf("red not big or price:250: %v", r)
+	}
+}
@@ -9,6 +9,9 @@ import (
 
 type HeaderCollector interface {
 	Add(key, value string)
+	// AddSub adds a header that is one of many values of key,
+	// like the counts per attribute value of a counter.
+	AddSub(key, subkey, value string)
 }
 
 // QueryOp is the interface for search queries implemented by everything
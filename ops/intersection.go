@@ -24,6 +24,9 @@ func (it *intersection) Add(n ...QueryOp) {
 }
 
 func (it intersection) CurrentDoc() *index.IbDoc {
+	if len(it) == 0 {
+		return nil
+	}
 	return it[0].CurrentDoc()
 }
 
@@ -9,14 +9,22 @@ import (
 )
 
 type limit struct {
-	lim  uint
-	next QueryOp
+	lim     uint
+	next    QueryOp
+	counted bool
 }
 
 func NewLimit(lim uint) QueryContainer {
 	return &limit{lim: lim}
 }
 
+// NewLimitCounted is a limit with counters below it. When the limit is
+// reached the rest of the documents are read so that the counters
+// count all of them.
+func NewLimitCounted(lim uint) QueryContainer {
+	return &limit{lim: lim, counted: true}
+}
+
 func (l *limit) Add(n ...QueryOp) {
 	if l.next != nil || len(n) != 1 {
 		log.Fatal("limit.Add multiple")
@@ -33,6 +41,14 @@ func (l limit) CurrentDoc() *index.IbDoc {
 
 func (l *limit) NextDoc(s *index.IbDoc) *index.IbDoc {
 	if l.lim == 0 {
+		if l.counted {
+			l.counted = false
+			search := *s
+			for d := l.next.NextDoc(&search); d != nil; d = l.next.NextDoc(&search) {
+				search = *d
+				search.Inc()
+			}
+		}
 		return nil
 	}
 	l.lim--
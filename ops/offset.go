@@ -26,13 +26,16 @@ func (o *offset) Add(n ...QueryOp) {
 
 func (o offset) CurrentDoc() *index.IbDoc {
 	d := o.next.CurrentDoc()
-	if o.offset == 0 {
+	if o.offset == 0 || d == nil {
 		return d
 	}
 	s := *d
 	for ; o.offset > 0; o.offset-- {
 		s.Inc()
 		d := o.next.NextDoc(&s)
+		if d == nil {
+			return nil
+		}
 		s = *d
 	}
 	return o.next.CurrentDoc()
@@ -42,6 +45,10 @@ func (o offset) CurrentDoc() *index.IbDoc {
 func (o *offset) NextDoc(s *index.IbDoc) *index.IbDoc {
 	for ; o.offset > 0; o.offset-- {
 		d := o.next.NextDoc(s)
+		if d == nil {
+			o.offset = 0
+			return nil
+		}
 		*s = *d
 		s.Inc()
 	}
@@ -0,0 +1,61 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops_test
+
+import (
+	"bsearch/index"
+	"bsearch/ops"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testIndex writes the documents added by add to a blob and opens it.
+func testIndex(t *testing.T, fields []string, add func(w *index.Writer)) *index.Index {
+	w := index.NewWriter(fields)
+	add(w)
+	name := filepath.Join(t.TempDir(), "db.blob")
+	if err := w.WriteFile(name); err != nil {
+		t.Fatal(err)
+	}
+	in, err := index.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(in.Close)
+	return in
+}
+
+// ids returns the ids of the documents of q in the order q returns them.
+func ids(q ops.QueryOp) string {
+	var r []uint32
+	s := index.NullDoc()
+	for d := q.NextDoc(s); d != nil; d = q.NextDoc(s) {
+		r = append(r, d.Id)
+		*s = *d
+		s.Inc()
+	}
+	return fmt.Sprint(r)
+}
+
+// wordIndex has documents with words at different distances from each
+// other and a price.
+func wordIndex(t *testing.T) *index.Index {
+	return testIndex(t, []string{"id", "text"}, func(w *index.Writer) {
+		for i, d := range []struct {
+			text, category, price string
+		}{
+			{"red big car", "1000", "100"},
+			{"big red car", "1000", "250"},
+			{"red car", "2000", "500"},
+			{"car is red", "2000", "501"},
+			{"red x x x car", "1000", "99"},
+		} {
+			id := uint32(i + 1)
+			w.AddDocument(index.IbDoc{Id: id, Order: id}, []string{fmt.Sprint(id), d.text},
+				[]string{"category:" + d.category, "price:" + d.price}, strings.Fields(d.text))
+		}
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+)
+
+// positioner is an op that knows the positions of a word in the
+// current document.
+type positioner interface {
+	QueryOp
+	Positions() []index.IbDocpos
+}
+
+// overlay is the documents of an op on the blob that haven't been
+// deleted in the overlay of the index together with the documents of
+// the same op on the overlay.
+type overlay struct {
+	in      *index.Index
+	base    QueryOp
+	added   QueryOp
+	cur     QueryOp
+	started bool
+}
+
+func newOverlay(in *index.Index, base, added QueryOp) *overlay {
+	return &overlay{in: in, base: base, added: added}
+}
+
+func (o *overlay) CurrentDoc() *index.IbDoc {
+	if !o.started {
+		return o.NextDoc(index.NullDoc())
+	}
+	if o.cur == nil {
+		return nil
+	}
+	return o.cur.CurrentDoc()
+}
+
+func (o *overlay) NextDoc(search *index.IbDoc) *index.IbDoc {
+	o.started = true
+	s := *search
+	b := o.base.NextDoc(&s)
+	for b != nil && o.in.Deleted(b.Id) {
+		s = *b
+		s.Inc()
+		b = o.base.NextDoc(&s)
+	}
+	s = *search
+	a := o.added.NextDoc(&s)
+	switch {
+	case a == nil && b == nil:
+		o.cur = nil
+		return nil
+	case a == nil || (b != nil && a.Less(*b)):
+		o.cur = o.base
+		return b
+	default:
+		o.cur = o.added
+		return a
+	}
+}
+
+// Positions returns the positions of the word in the current document
+// for overlays of words.
+func (o overlay) Positions() []index.IbDocpos {
+	return o.cur.(positioner).Positions()
+}
+
+func (o overlay) ProcessHeaders(hc HeaderCollector) {
+	o.base.ProcessHeaders(hc)
+	o.added.ProcessHeaders(hc)
+}
@@ -0,0 +1,159 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+	"sort"
+)
+
+type phrase struct {
+	words []positioner
+	it    QueryOp
+	exact bool
+	near  int
+	dl    *Deadline
+	// The last document that matched, only set once started.
+	cur     *index.IbDoc
+	started bool
+}
+
+// QueryOp that is the set of documents where the words appear next to each
+// other in the given order.
+func NewPhrase(in *index.Index, words ...string) QueryOp {
+	return newPhrase(in, true, 0, words)
+}
+
+// QueryOp that is the set of documents where all the words appear, in any
+// order, within a window of k positions.
+func NewNear(in *index.Index, k int, words ...string) QueryOp {
+	return newPhrase(in, false, k, words)
+}
+
+func newPhrase(in *index.Index, exact bool, near int, words []string) QueryOp {
+	p := &phrase{exact: exact, near: near}
+	it := NewIntersection()
+	for _, w := range words {
+		wo := NewWord(in, w).(positioner)
+		p.words = append(p.words, wo)
+		it.Add(wo)
+	}
+	p.it = it
+	return p
+}
+
+func (p *phrase) setDeadline(dl *Deadline) {
+	p.dl = dl
+}
+
+// CurrentDoc returns the last document that matched the positions,
+// the current document of the intersection might not.
+func (p *phrase) CurrentDoc() *index.IbDoc {
+	if !p.started {
+		return p.NextDoc(index.NullDoc())
+	}
+	return p.cur
+}
+
+func (p *phrase) NextDoc(search *index.IbDoc) *index.IbDoc {
+	p.started = true
+	p.cur = nil
+	s := *search
+	for {
+		// Frequent words that are never next to each other loop
+		// here through all their documents.
+		if p.dl.stop() {
+			return nil
+		}
+		d := p.it.NextDoc(&s)
+		if d == nil {
+			return nil
+		}
+		if p.match() {
+			p.cur = d
+			return d
+		}
+		s = *d
+		s.Inc()
+	}
+}
+
+// match checks the positions of the words in the current document
+// of the intersection.
+func (p phrase) match() bool {
+	pos := make([][]int, len(p.words))
+	for i, w := range p.words {
+		for _, dp := range w.Positions() {
+			pos[i] = append(pos[i], int(dp.Pos))
+		}
+		sort.Ints(pos[i])
+	}
+	if p.exact {
+		return adjacent(pos)
+	}
+	return within(pos, p.near)
+}
+
+// adjacent returns true if there is a position p in pos[0] such that
+// p+i is in pos[i] for all the other words.
+func adjacent(pos [][]int) bool {
+	for _, p0 := range pos[0] {
+		i := 1
+		for ; i < len(pos); i++ {
+			j := sort.SearchInts(pos[i], p0+i)
+			if j == len(pos[i]) || pos[i][j] != p0+i {
+				break
+			}
+		}
+		if i == len(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+type occurrence struct {
+	pos, word int
+}
+
+type occurrences []occurrence
+
+func (o occurrences) Len() int           { return len(o) }
+func (o occurrences) Less(i, j int) bool { return o[i].pos < o[j].pos }
+func (o occurrences) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+
+// within returns true if there is a window of at most k positions
+// that contains at least one position of every word.
+func within(pos [][]int, k int) bool {
+	var all occurrences
+	for w, ps := range pos {
+		for _, p := range ps {
+			all = append(all, occurrence{p, w})
+		}
+	}
+	sort.Sort(all)
+
+	seen := make([]int, len(pos))
+	have, l := 0, 0
+	for _, o := range all {
+		if seen[o.word] == 0 {
+			have++
+		}
+		seen[o.word]++
+		for have == len(pos) {
+			if o.pos-all[l].pos <= k {
+				return true
+			}
+			seen[all[l].word]--
+			if seen[all[l].word] == 0 {
+				have--
+			}
+			l++
+		}
+	}
+	return false
+}
+
+func (p phrase) ProcessHeaders(hc HeaderCollector) {
+}
@@ -0,0 +1,48 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops_test
+
+import (
+	"bsearch/ops"
+	"testing"
+)
+
+func TestPhrase(t *testing.T) {
+	in := wordIndex(t)
+
+	for i, c := range []struct {
+		q   ops.QueryOp
+		res string
+	}{
+		{ops.NewPhrase(in, "red", "car"), "[3 2]"},
+		{ops.NewPhrase(in, "car", "red"), "[]"},
+		{ops.NewPhrase(in, "red", "big", "car"), "[1]"},
+		{ops.NewNear(in, 1, "red", "car"), "[3 2]"},
+		{ops.NewNear(in, 2, "red", "car"), "[4 3 2 1]"},
+		{ops.NewNear(in, 2, "car", "red"), "[4 3 2 1]"},
+		{ops.NewNear(in, 4, "red", "car"), "[5 4 3 2 1]"},
+		{ops.NewNear(in, 1, "big", "car"), "[1]"},
+	} {
+		if r := ids(c.q); r != c.res {
+			t.Errorf("%d: %v, expected %v", i, r, c.res)
+		}
+	}
+}
+
+func TestPhraseInUnion(t *testing.T) {
+	in := wordIndex(t)
+
+	// The union looks at CurrentDoc before and between the NextDoc
+	// calls, doc 5 has both words but not next to each other.
+	un := ops.NewUnion()
+	un.Add(ops.NewPhrase(in, "red", "car"), ops.NewAttr(in, "zzz:1"))
+	if r := ids(un); r != "[3 2]" {
+		t.Errorf("red car or zzz:1: %v", r)
+	}
+	un = ops.NewUnion()
+	un.Add(ops.NewNear(in, 1, "big", "car"), ops.NewAttr(in, "category:2000"))
+	if r := ids(un); r != "[4 3 1]" {
+		t.Errorf("big near car or category:2000: %v", r)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+	"log"
+	"math/rand"
+)
+
+type random struct {
+	seed int64
+	next QueryOp
+	docs QueryOp
+	dl   *Deadline
+	rk   *Ranks
+}
+
+// NewRandom returns the documents of the contained QueryOp in a
+// pseudo-random order decided by seed. The same seed and set of
+// documents always give the same order, so paging with offset and
+// limit on top of this is consistent between queries.
+func NewRandom(seed int64) QueryContainer {
+	return &random{seed: seed}
+}
+
+func (r *random) Add(n ...QueryOp) {
+	if r.next != nil || len(n) != 1 {
+		log.Fatal("random.Add multiple")
+	}
+	r.next = n[0]
+}
+
+func (r *random) setDeadline(dl *Deadline) {
+	r.dl = dl
+}
+
+func (r *random) setRanks(rk *Ranks) {
+	r.rk = rk
+}
+
+func (r *random) collect() {
+	docs := drain(r.next, r.dl)
+	perm := rand.New(rand.NewSource(r.seed)).Perm(len(docs))
+	shuffled := make([]index.IbDoc, len(docs))
+	for i, p := range perm {
+		shuffled[i] = docs[p]
+	}
+	r.docs = renumber(shuffled, r.rk)
+}
+
+func (r *random) CurrentDoc() *index.IbDoc {
+	if r.docs == nil {
+		r.collect()
+	}
+	return r.docs.CurrentDoc()
+}
+
+func (r *random) NextDoc(s *index.IbDoc) *index.IbDoc {
+	if r.docs == nil {
+		r.collect()
+	}
+	return r.docs.NextDoc(s)
+}
+
+func (r random) ProcessHeaders(hc HeaderCollector) {
+	r.next.ProcessHeaders(hc)
+}
+
+// drain returns all the documents of q, or the ones found until dl
+// expired.
+func drain(q QueryOp, dl *Deadline) []index.IbDoc {
+	var docs []index.IbDoc
+
+	search := index.NullDoc()
+	for {
+		if dl.stop() {
+			break
+		}
+		d := q.NextDoc(search)
+		if d == nil {
+			break
+		}
+		docs = append(docs, *d)
+		*search = *d
+		search.Inc()
+	}
+	return docs
+}
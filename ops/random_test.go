@@ -0,0 +1,47 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops_test
+
+import (
+	"bsearch/index"
+	"bsearch/ops"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRandomPaging(t *testing.T) {
+	in := testIndex(t, []string{"id"}, func(w *index.Writer) {
+		for id := uint32(1); id <= 20; id++ {
+			w.AddDocument(index.IbDoc{Id: id, Order: id}, []string{fmt.Sprint(id)}, []string{"a:a"}, nil)
+		}
+	})
+	random := func(seed int64) ops.QueryContainer {
+		r := ops.NewRandom(seed)
+		r.Add(ops.NewAttr(in, "a:a"))
+		return r
+	}
+
+	all := ids(random(4711))
+	if all != ids(random(4711)) {
+		t.Errorf("same seed, different order")
+	}
+	if all == ids(random(17)) || all == ids(ops.NewAttr(in, "a:a")) {
+		t.Errorf("not shuffled: %v", all)
+	}
+
+	// Pages of 6 like "<offset> lim:6 rand:4711 a:a", the limit counts
+	// the documents skipped by the offset too.
+	var pages []string
+	for off := uint(0); off < 20; off += 6 {
+		l := ops.NewLimit(off + 6)
+		l.Add(random(4711))
+		o := ops.NewOffset(off)
+		o.Add(l)
+		pages = append(pages, strings.Trim(ids(o), "[]"))
+	}
+	if p := "[" + strings.Join(pages, " ") + "]"; p != all {
+		t.Errorf("pages %v, expected %v", p, all)
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+)
+
+// QueryOp that is the set of documents that have a numeric value of the
+// attribute name between low and high inclusive.
+func NewRange(in *index.Index, name string, low, high int64) QueryOp {
+	un := NewUnion()
+	for _, k := range in.AttrRange(name, low, high) {
+		un.Add(NewAttr(in, k))
+	}
+	return un
+}
@@ -0,0 +1,31 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops_test
+
+import (
+	"bsearch/ops"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	in := wordIndex(t)
+
+	for _, c := range []struct {
+		low, high int64
+		res       string
+	}{
+		{100, 500, "[3 2 1]"},
+		{99, 99, "[5]"},
+		{501, 1000, "[4]"},
+		{600, 700, "[]"},
+		{500, 100, "[]"},
+	} {
+		if r := ids(ops.NewRange(in, "price", c.low, c.high)); r != c.res {
+			t.Errorf("price:%v-%v: %v, expected %v", c.low, c.high, r, c.res)
+		}
+	}
+	if r := ids(ops.NewRange(in, "nope", 0, 1000)); r != "[]" {
+		t.Errorf("nope:0-1000: %v", r)
+	}
+}
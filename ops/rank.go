@@ -0,0 +1,66 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+)
+
+// Ranks keeps the documents that sort and rand reorder as they are in
+// the index. Ops return documents in Order, so sort and rand return
+// them with their rank as Order. Restore puts back the real Order and
+// Suborder when the query is done, they are what the results are
+// merged on and what http returns in sort_keys.
+//
+// Ranks are used by one query on one goroutine.
+type Ranks struct {
+	orig map[uint32]index.IbDoc
+}
+
+func NewRanks() *Ranks {
+	return &Ranks{orig: make(map[uint32]index.IbDoc)}
+}
+
+// ranker is an op that returns documents with their rank as Order.
+type ranker interface {
+	setRanks(rk *Ranks)
+}
+
+// Attach gives the ranks to q if it reorders documents.
+func (rk *Ranks) Attach(q QueryOp) {
+	if r, ok := q.(ranker); ok && rk != nil {
+		r.setRanks(rk)
+	}
+}
+
+// Restore replaces the documents in docs that were reordered with the
+// documents in the index.
+func (rk *Ranks) Restore(docs []*index.IbDoc) {
+	if rk == nil || len(rk.orig) == 0 {
+		return
+	}
+	for i, d := range docs {
+		if o, exists := rk.orig[d.Id]; exists {
+			docs[i] = &o
+		}
+	}
+}
+
+// renumber returns a QueryOp that returns the documents in the order
+// they are in docs. Order is rewritten to make that happen, ops only
+// look at Id when a document leaves the query. The documents are kept
+// in rk. When sort and rand are nested the inner one keeps them first,
+// before they are renumbered.
+func renumber(docs []index.IbDoc, rk *Ranks) QueryOp {
+	a := attr(docs)
+	for i := range a {
+		if rk != nil {
+			if _, exists := rk.orig[a[i].Id]; !exists {
+				rk.orig[a[i].Id] = a[i]
+			}
+		}
+		a[i].Order = uint32(len(a) - i)
+	}
+	return &a
+}
@@ -0,0 +1,123 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+	"log"
+	"sort"
+	"strconv"
+)
+
+type sorter struct {
+	in       *index.Index
+	col      int
+	suborder bool
+	desc     bool
+	next     QueryOp
+	docs     QueryOp
+	dl       *Deadline
+	rk       *Ranks
+}
+
+// NewSort returns the documents of the contained QueryOp sorted on
+// the document field. If there is no such field and field is
+// "suborder" the documents are sorted on their Suborder. Values that
+// are numbers are compared as numbers and come before all the other
+// values, which are compared as strings. Documents with equal values
+// keep their original order.
+func NewSort(in *index.Index, field string, desc bool) QueryContainer {
+	so := &sorter{in: in, col: in.Field(field), desc: desc}
+	so.suborder = so.col == -1 && field == "suborder"
+	return so
+}
+
+func (so *sorter) Add(n ...QueryOp) {
+	if so.next != nil || len(n) != 1 {
+		log.Fatal("sorter.Add multiple")
+	}
+	so.next = n[0]
+}
+
+type sortKey struct {
+	s     string
+	n     int64
+	isnum bool
+}
+
+type sortDocs struct {
+	docs []index.IbDoc
+	keys []sortKey
+	desc bool
+}
+
+func (sd sortDocs) Len() int {
+	return len(sd.docs)
+}
+
+func (sd sortDocs) Less(i, j int) bool {
+	a, b := sd.keys[i], sd.keys[j]
+	// Numbers come first in both directions.
+	if a.isnum != b.isnum {
+		return a.isnum
+	}
+	if sd.desc {
+		a, b = b, a
+	}
+	if a.isnum {
+		return a.n < b.n
+	}
+	return a.s < b.s
+}
+
+func (sd sortDocs) Swap(i, j int) {
+	sd.docs[i], sd.docs[j] = sd.docs[j], sd.docs[i]
+	sd.keys[i], sd.keys[j] = sd.keys[j], sd.keys[i]
+}
+
+func (so *sorter) setDeadline(dl *Deadline) {
+	so.dl = dl
+}
+
+func (so *sorter) setRanks(rk *Ranks) {
+	so.rk = rk
+}
+
+func (so *sorter) collect() {
+	sd := sortDocs{docs: drain(so.next, so.dl), desc: so.desc}
+	sd.keys = make([]sortKey, len(sd.docs))
+	for i, d := range sd.docs {
+		k := &sd.keys[i]
+		if so.suborder {
+			k.n, k.isnum = int64(d.Suborder), true
+			continue
+		}
+		if so.col == -1 {
+			continue
+		}
+		k.s = so.in.DocField(d.Id, so.col)
+		n, err := strconv.ParseInt(k.s, 10, 64)
+		k.n, k.isnum = n, err == nil
+	}
+	sort.Stable(sd)
+	so.docs = renumber(sd.docs, so.rk)
+}
+
+func (so *sorter) CurrentDoc() *index.IbDoc {
+	if so.docs == nil {
+		so.collect()
+	}
+	return so.docs.CurrentDoc()
+}
+
+func (so *sorter) NextDoc(s *index.IbDoc) *index.IbDoc {
+	if so.docs == nil {
+		so.collect()
+	}
+	return so.docs.NextDoc(s)
+}
+
+func (so sorter) ProcessHeaders(hc HeaderCollector) {
+	so.next.ProcessHeaders(hc)
+}
@@ -0,0 +1,73 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops_test
+
+import (
+	"bsearch/index"
+	"bsearch/ops"
+	"bsearch/parser"
+	"bsearch/parser/opers"
+	"fmt"
+	"strings"
+	"testing"
+	"github.com/art4711/timers"
+)
+
+func TestSort(t *testing.T) {
+	in := testIndex(t, []string{"id", "v"}, func(w *index.Writer) {
+		for i, v := range []string{"10", "9", "b", "a", "2", "x"} {
+			id := uint32(i + 1)
+			w.AddDocument(index.IbDoc{Id: id, Order: id * 10, Suborder: id}, []string{fmt.Sprint(id), v}, []string{"a:a"}, nil)
+		}
+	})
+
+	for _, c := range []struct {
+		q, res string
+	}{
+		{`(sort "v" (attr "a:a"))`, "[5 2 1 4 3 6]"},
+		{`(sort "v" [ desc ] (attr "a:a"))`, "[1 2 5 6 3 4]"},
+		{`sort:-v a:a`, "[1 2 5 6 3 4]"},
+		{`(sort "suborder" [ desc ] (attr "a:a"))`, "[6 5 4 3 2 1]"},
+		{`(sort "v" (rand [ 4711 ] (attr "a:a")))`, "[5 2 1 4 3 6]"},
+	} {
+		var o *opers.Op
+		var errs []error
+		if strings.HasPrefix(c.q, "(") {
+			o, errs = parser.ParseStructured(c.q, timers.New().Start("parse"))
+		} else {
+			o, errs = parser.ParseClassic(c.q)
+		}
+		if errs != nil {
+			t.Fatal(errs)
+		}
+		rk := ops.NewRanks()
+		q, errs := o.GenerateDeadline(in, nil, rk)
+		if errs != nil {
+			t.Fatal(errs)
+		}
+		var docs []*index.IbDoc
+		s := index.NullDoc()
+		for d := q.NextDoc(s); d != nil; d = q.NextDoc(s) {
+			docs = append(docs, d)
+			*s = *d
+			s.Inc()
+		}
+		rk.Restore(docs)
+		var ids []uint32
+		for _, d := range docs {
+			ids = append(ids, d.Id)
+			if d.Order != d.Id*10 || d.Suborder != d.Id {
+				t.Errorf("%v: document %v has order %v/%v", c.q, d.Id, d.Order, d.Suborder)
+			}
+		}
+		if r := fmt.Sprint(ids); r != c.res {
+			t.Errorf("%v: %v, expected %v", c.q, r, c.res)
+		}
+	}
+
+	o, _ := parser.ParseClassic("sort:nope a:a")
+	if _, errs := o.Generate(in); len(errs) != 1 || errs[0] != opers.ErrSortField {
+		t.Errorf("sort on unknown field: %v", errs)
+	}
+}
@@ -28,11 +28,14 @@ func (un *union) Add(nodes ...QueryOp) {
 }
 
 func (un union) CurrentDoc() *index.IbDoc {
+	if len(un) == 0 {
+		return nil
+	}
 	return un[0].CurrentDoc()
 }
 
 func (un *union) NextDoc(search *index.IbDoc) *index.IbDoc {
-	d := (*un)[0].CurrentDoc()
+	d := un.CurrentDoc()
 	// Chew up all documents bigger than search
 	for d != nil && search.Less(*d) {
 		if (*un)[0].NextDoc(search) != nil {
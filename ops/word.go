@@ -0,0 +1,71 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops
+
+import (
+	"bsearch/index"
+)
+
+type word struct {
+	w    index.Word
+	docs []index.IbDocindex
+}
+
+// QueryOp that is the set of all documents containing a word.
+func NewWord(in *index.Index, w string) QueryOp {
+	iw, _ := in.Word(w)
+	wo := &word{w: iw, docs: iw.Docs}
+	if !in.HasOverlay() {
+		return wo
+	}
+	ow, _ := in.OverlayWord(w)
+	return newOverlay(in, wo, &word{w: ow, docs: ow.Docs})
+}
+
+func (w word) CurrentDoc() *index.IbDoc {
+	if len(w.docs) == 0 {
+		return nil
+	}
+	return &w.docs[0].Doc
+}
+
+func (w *word) NextDoc(search *index.IbDoc) *index.IbDoc {
+	// Same search as in attr.NextDoc, see the comments there.
+	const firstLinear = 5
+	const leftBias = 16
+
+	l := len(w.docs)
+
+	start := 0
+	for start = 0; start < l && start < firstLinear; start++ {
+		if w.docs[start].Doc.LessEqual(*search) {
+			w.docs = w.docs[start:]
+			return &w.docs[0].Doc
+		}
+	}
+
+	i, j := start, l
+	for i < j {
+		h := i + (j-i)/leftBias
+		if w.docs[h].Doc.LessEqual(*search) {
+			j = h
+		} else {
+			i = h + 1
+		}
+	}
+
+	w.docs = w.docs[i:]
+	if i == l {
+		return nil
+	}
+	return &w.docs[0].Doc
+}
+
+// Positions returns the positions of the word in the current document.
+func (w word) Positions() []index.IbDocpos {
+	return w.w.Positions(&w.docs[0])
+}
+
+func (w word) ProcessHeaders(hc HeaderCollector) {
+}
@@ -0,0 +1,69 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package ops_test
+
+import (
+	"bsearch/index"
+	"bsearch/ops"
+	"bsearch/parser"
+	"fmt"
+	"testing"
+)
+
+func TestWord(t *testing.T) {
+	in := wordIndex(t)
+
+	if r := ids(ops.NewWord(in, "big")); r != "[2 1]" {
+		t.Errorf("big: %v", r)
+	}
+	if r := ids(ops.NewWord(in, "bicycle")); r != "[]" {
+		t.Errorf("bicycle: %v", r)
+	}
+	it := ops.NewIntersection()
+	it.Add(ops.NewWord(in, "red"), ops.NewAttr(in, "category:1000"))
+	if r := ids(it); r != "[5 2 1]" {
+		t.Errorf("red in category:1000: %v", r)
+	}
+	un := ops.NewUnion()
+	un.Add(ops.NewWord(in, "big"), ops.NewAttr(in, "category:2000"))
+	if r := ids(un); r != "[4 3 2 1]" {
+		t.Errorf("big or category:2000: %v", r)
+	}
+}
+
+func TestQueryWords(t *testing.T) {
+	// Two word fields the way bsearch-index indexes them.
+	in := testIndex(t, []string{"id"}, func(w *index.Writer) {
+		for i, d := range [][2]string{{"E-mail me", "red car"}, {"email", "me red"}} {
+			wds := index.SplitWords(d[0])
+			wds = append(wds, make([]string, index.FieldGap)...)
+			wds = append(wds, index.SplitWords(d[1])...)
+			id := uint32(i + 1)
+			w.AddDocument(index.IbDoc{Id: id, Order: id}, []string{fmt.Sprint(id)}, nil, wds)
+		}
+	})
+
+	for _, c := range []struct {
+		q, res string
+	}{
+		{`*:* e-mail`, "[1]"},
+		{`*:* E-MAIL`, "[1]"},
+		{`*:* "e-mail me"`, "[1]"},
+		{`*:* "me red"`, "[2]"},
+		{`*:* email`, "[2]"},
+		{`*:* -`, "[]"},
+	} {
+		o, errs := parser.ParseClassic(c.q)
+		if errs != nil {
+			t.Fatalf("%v: %v", c.q, errs)
+		}
+		q, errs := o.Generate(in)
+		if errs != nil {
+			t.Fatalf("%v: %v", c.q, errs)
+		}
+		if r := ids(q); r != c.res {
+			t.Errorf("%v: %v, expected %v", c.q, r, c.res)
+		}
+	}
+}
@@ -23,24 +23,37 @@ const (
 	RuleQ3
 	RuleOffset
 	RuleLimit
+	RuleReorder
+	RuleRand
+	RuleSort
 	RuleParams
-	RuleCountAllAttrs
+	RuleCountersAttrs
+	RuleCounter
 	RuleCountAll
+	RuleCountBy
 	RuleAttrs
+	RuleAttrList
 	RuleAttr
 	RuleAttribute
 	RuleAttrUnion
 	RuleAttributeORList
+	RuleRange
+	RuleNotAttr
+	RuleKeywords
+	RuleKeyword
+	RuleWord
+	RulePhrase
 	Rulenumber
 	Rules
 	Rulecounter_name
 	Ruleattr_name
 	Ruleattr_value
 	Rulegeneric_name
+	Ruleword
 	RuleAction0
 	RuleAction1
-	RulePegText
 	RuleAction2
+	RulePegText
 	RuleAction3
 	RuleAction4
 	RuleAction5
@@ -50,6 +63,13 @@ const (
 	RuleAction9
 	RuleAction10
 	RuleAction11
+	RuleAction12
+	RuleAction13
+	RuleAction14
+	RuleAction15
+	RuleAction16
+	RuleAction17
+	RuleAction18
 
 	RulePre_
 	Rule_In_
@@ -65,24 +85,37 @@ var Rul3s = [...]string{
 	"Q3",
 	"Offset",
 	"Limit",
+	"Reorder",
+	"Rand",
+	"Sort",
 	"Params",
-	"CountAllAttrs",
+	"CountersAttrs",
+	"Counter",
 	"CountAll",
+	"CountBy",
 	"Attrs",
+	"AttrList",
 	"Attr",
 	"Attribute",
 	"AttrUnion",
 	"AttributeORList",
+	"Range",
+	"NotAttr",
+	"Keywords",
+	"Keyword",
+	"Word",
+	"Phrase",
 	"number",
 	"s",
 	"counter_name",
 	"attr_name",
 	"attr_value",
 	"generic_name",
+	"word",
 	"Action0",
 	"Action1",
-	"PegText",
 	"Action2",
+	"PegText",
 	"Action3",
 	"Action4",
 	"Action5",
@@ -92,6 +125,13 @@ var Rul3s = [...]string{
 	"Action9",
 	"Action10",
 	"Action11",
+	"Action12",
+	"Action13",
+	"Action14",
+	"Action15",
+	"Action16",
+	"Action17",
+	"Action18",
 
 	"Pre_",
 	"_In_",
@@ -596,7 +636,7 @@ type Parser struct {
 
 	Buffer string
 	buffer []rune
-	rules  [35]func() bool
+	rules  [55]func() bool
 	Parse  func(rule ...int) error
 	Reset  func()
 	TokenTree
@@ -676,26 +716,39 @@ func (p *Parser) Execute() {
 		case RuleAction1:
 			p.Pa()
 		case RuleAction2:
-			p.Off(buffer[begin:end])
+			p.Pa()
 		case RuleAction3:
-			p.Lim(buffer[begin:end])
+			p.Off(string([]rune(buffer)[begin:end]))
 		case RuleAction4:
-			p.Pa()
+			p.Lim(string([]rune(buffer)[begin:end]))
 		case RuleAction5:
-			p.Countall(buffer[begin:end])
+			p.Rand(string([]rune(buffer)[begin:end]))
 		case RuleAction6:
-			p.Inter()
+			p.Sort(string([]rune(buffer)[begin:end]))
 		case RuleAction7:
-			p.Inter()
-			fmt.Printf("one attribute\n")
+			p.Pa()
 		case RuleAction8:
-			fmt.Printf("no attributes\n")
+			p.Countall(string([]rune(buffer)[begin:end]))
 		case RuleAction9:
-			p.Attr(buffer[begin:end])
+			p.Countby(string([]rune(buffer)[begin:end]))
 		case RuleAction10:
-			p.Union()
+			p.Inter()
 		case RuleAction11:
+			p.Attr(string([]rune(buffer)[begin:end]))
+		case RuleAction12:
+			p.Union()
+		case RuleAction13:
 			p.Pa()
+		case RuleAction14:
+			p.Range(string([]rune(buffer)[begin:end]))
+		case RuleAction15:
+			p.Not()
+		case RuleAction16:
+			p.Pa()
+		case RuleAction17:
+			p.Word(string([]rune(buffer)[begin:end]))
+		case RuleAction18:
+			p.Phrase(string([]rune(buffer)[begin:end]))
 
 		}
 	}
@@ -871,128 +924,124 @@ func (p *Parser) Init() {
 			position, tokenIndex, depth = position9, tokenIndex9, depth9
 			return false
 		},
-		/* 4 Q3 <- <Params?> */
+		/* 4 Q3 <- <((Reorder Params? Action2) / Params?)> */
 		func() bool {
 			{
 				position14 := position
 				depth++
 				{
 					position15, tokenIndex15, depth15 := position, tokenIndex, depth
-					if !rules[RuleParams]() {
-						goto l15
+					if !rules[RuleReorder]() {
+						goto l16
+					}
+					{
+						position17, tokenIndex17, depth17 := position, tokenIndex, depth
+						if !rules[RuleParams]() {
+							goto l17
+						}
+						goto l18
+					l17:
+						position, tokenIndex, depth = position17, tokenIndex17, depth17
 					}
-					goto l16
-				l15:
+				l18:
+					if !rules[RuleAction2]() {
+						goto l16
+					}
+					goto l15
+				l16:
 					position, tokenIndex, depth = position15, tokenIndex15, depth15
+					{
+						position19, tokenIndex19, depth19 := position, tokenIndex, depth
+						if !rules[RuleParams]() {
+							goto l19
+						}
+						goto l20
+					l19:
+						position, tokenIndex, depth = position19, tokenIndex19, depth19
+					}
+				l20:
 				}
-			l16:
+			l15:
 				depth--
 				add(RuleQ3, position14)
 			}
 			return true
 		},
-		/* 5 Offset <- <(<number> s Action2)> */
+		/* 5 Offset <- <(<number> s Action3)> */
 		func() bool {
-			position17, tokenIndex17, depth17 := position, tokenIndex, depth
+			position21, tokenIndex21, depth21 := position, tokenIndex, depth
 			{
-				position18 := position
+				position22 := position
 				depth++
 				{
-					position19 := position
+					position23 := position
 					depth++
 					if !rules[Rulenumber]() {
-						goto l17
+						goto l21
 					}
 					depth--
-					add(RulePegText, position19)
+					add(RulePegText, position23)
 				}
 				if !rules[Rules]() {
-					goto l17
+					goto l21
 				}
-				if !rules[RuleAction2]() {
-					goto l17
+				if !rules[RuleAction3]() {
+					goto l21
 				}
 				depth--
-				add(RuleOffset, position18)
+				add(RuleOffset, position22)
 			}
 			return true
-		l17:
-			position, tokenIndex, depth = position17, tokenIndex17, depth17
+		l21:
+			position, tokenIndex, depth = position21, tokenIndex21, depth21
 			return false
 		},
-		/* 6 Limit <- <('l' 'i' 'm' ':' <number> s Action3)> */
+		/* 6 Limit <- <('l' 'i' 'm' ':' <number> s Action4)> */
 		func() bool {
-			position20, tokenIndex20, depth20 := position, tokenIndex, depth
+			position24, tokenIndex24, depth24 := position, tokenIndex, depth
 			{
-				position21 := position
+				position25 := position
 				depth++
 				if buffer[position] != rune('l') {
-					goto l20
+					goto l24
 				}
 				position++
 				if buffer[position] != rune('i') {
-					goto l20
+					goto l24
 				}
 				position++
 				if buffer[position] != rune('m') {
-					goto l20
+					goto l24
 				}
 				position++
 				if buffer[position] != rune(':') {
-					goto l20
+					goto l24
 				}
 				position++
 				{
-					position22 := position
+					position26 := position
 					depth++
 					if !rules[Rulenumber]() {
-						goto l20
+						goto l24
 					}
 					depth--
-					add(RulePegText, position22)
+					add(RulePegText, position26)
 				}
 				if !rules[Rules]() {
-					goto l20
-				}
-				if !rules[RuleAction3]() {
-					goto l20
+					goto l24
 				}
-				depth--
-				add(RuleLimit, position21)
-			}
-			return true
-		l20:
-			position, tokenIndex, depth = position20, tokenIndex20, depth20
-			return false
-		},
-		/* 7 Params <- <(CountAllAttrs / Attrs)> */
-		func() bool {
-			position23, tokenIndex23, depth23 := position, tokenIndex, depth
-			{
-				position24 := position
-				depth++
-				{
-					position25, tokenIndex25, depth25 := position, tokenIndex, depth
-					if !rules[RuleCountAllAttrs]() {
-						goto l26
-					}
-					goto l25
-				l26:
-					position, tokenIndex, depth = position25, tokenIndex25, depth25
-					if !rules[RuleAttrs]() {
-						goto l23
-					}
+				if !rules[RuleAction4]() {
+					goto l24
 				}
-			l25:
 				depth--
-				add(RuleParams, position24)
+				add(RuleLimit, position25)
 			}
 			return true
-		l23:
-			position, tokenIndex, depth = position23, tokenIndex23, depth23
+		l24:
+			position, tokenIndex, depth = position24, tokenIndex24, depth24
 			return false
 		},
-		/* 8 CountAllAttrs <- <((CountAll Attrs Action4) / Attrs)> */
+		/* 7 Reorder <- <(Rand / Sort)> */
 		func() bool {
 			position27, tokenIndex27, depth27 := position, tokenIndex, depth
 			{
@@ -1000,58 +1049,32 @@ func (p *Parser) Init() {
 				depth++
 				{
 					position29, tokenIndex29, depth29 := position, tokenIndex, depth
-					if !rules[RuleCountAll]() {
-						goto l30
-					}
-					if !rules[RuleAttrs]() {
-						goto l30
-					}
-					if !rules[RuleAction4]() {
+					if !rules[RuleRand]() {
 						goto l30
 					}
 					goto l29
 				l30:
 					position, tokenIndex, depth = position29, tokenIndex29, depth29
-					if !rules[RuleAttrs]() {
+					if !rules[RuleSort]() {
 						goto l27
 					}
 				}
 			l29:
 				depth--
-				add(RuleCountAllAttrs, position28)
+				add(RuleReorder, position28)
 			}
 			return true
 		l27:
 			position, tokenIndex, depth = position27, tokenIndex27, depth27
 			return false
 		},
-		/* 9 CountAll <- <('c' 'o' 'u' 'n' 't' '_' 'a' 'l' 'l' '(' <counter_name> ')' s Action5)> */
+		/* 8 Rand <- <('r' 'a' 'n' 'd' ':' <number> s Action5)> */
 		func() bool {
 			position31, tokenIndex31, depth31 := position, tokenIndex, depth
 			{
 				position32 := position
 				depth++
-				if buffer[position] != rune('c') {
-					goto l31
-				}
-				position++
-				if buffer[position] != rune('o') {
-					goto l31
-				}
-				position++
-				if buffer[position] != rune('u') {
-					goto l31
-				}
-				position++
-				if buffer[position] != rune('n') {
-					goto l31
-				}
-				position++
-				if buffer[position] != rune('t') {
-					goto l31
-				}
-				position++
-				if buffer[position] != rune('_') {
+				if buffer[position] != rune('r') {
 					goto l31
 				}
 				position++
@@ -1059,31 +1082,27 @@ func (p *Parser) Init() {
 					goto l31
 				}
 				position++
-				if buffer[position] != rune('l') {
+				if buffer[position] != rune('n') {
 					goto l31
 				}
 				position++
-				if buffer[position] != rune('l') {
+				if buffer[position] != rune('d') {
 					goto l31
 				}
 				position++
-				if buffer[position] != rune('(') {
+				if buffer[position] != rune(':') {
 					goto l31
 				}
 				position++
 				{
 					position33 := position
 					depth++
-					if !rules[Rulecounter_name]() {
+					if !rules[Rulenumber]() {
 						goto l31
 					}
 					depth--
 					add(RulePegText, position33)
 				}
-				if buffer[position] != rune(')') {
-					goto l31
-				}
-				position++
 				if !rules[Rules]() {
 					goto l31
 				}
@@ -1091,102 +1110,134 @@ func (p *Parser) Init() {
 					goto l31
 				}
 				depth--
-				add(RuleCountAll, position32)
+				add(RuleRand, position32)
 			}
 			return true
 		l31:
 			position, tokenIndex, depth = position31, tokenIndex31, depth31
 			return false
 		},
-		/* 10 Attrs <- <((Action6 (Attr s)+ Attr s?) / (Action7 Attr s?) / (s? Action8))> */
+		/* 9 Sort <- <('s' 'o' 'r' 't' ':' <('-'? attr_name)> s Action6)> */
 		func() bool {
 			position34, tokenIndex34, depth34 := position, tokenIndex, depth
 			{
 				position35 := position
 				depth++
+				if buffer[position] != rune('s') {
+					goto l34
+				}
+				position++
+				if buffer[position] != rune('o') {
+					goto l34
+				}
+				position++
+				if buffer[position] != rune('r') {
+					goto l34
+				}
+				position++
+				if buffer[position] != rune('t') {
+					goto l34
+				}
+				position++
+				if buffer[position] != rune(':') {
+					goto l34
+				}
+				position++
 				{
-					position36, tokenIndex36, depth36 := position, tokenIndex, depth
-					if !rules[RuleAction6]() {
-						goto l37
-					}
-					if !rules[RuleAttr]() {
-						goto l37
-					}
-					if !rules[Rules]() {
-						goto l37
-					}
-				l38:
+					position36 := position
+					depth++
 					{
-						position39, tokenIndex39, depth39 := position, tokenIndex, depth
-						if !rules[RuleAttr]() {
-							goto l39
-						}
-						if !rules[Rules]() {
-							goto l39
+						position37, tokenIndex37, depth37 := position, tokenIndex, depth
+						if buffer[position] != rune('-') {
+							goto l37
 						}
+						position++
 						goto l38
-					l39:
-						position, tokenIndex, depth = position39, tokenIndex39, depth39
+					l37:
+						position, tokenIndex, depth = position37, tokenIndex37, depth37
 					}
-					if !rules[RuleAttr]() {
-						goto l37
+				l38:
+					if !rules[Ruleattr_name]() {
+						goto l34
 					}
-					{
-						position40, tokenIndex40, depth40 := position, tokenIndex, depth
-						if !rules[Rules]() {
-							goto l40
-						}
-						goto l41
-					l40:
-						position, tokenIndex, depth = position40, tokenIndex40, depth40
-					}
-				l41:
-					goto l36
-				l37:
-					position, tokenIndex, depth = position36, tokenIndex36, depth36
-					if !rules[RuleAction7]() {
+					depth--
+					add(RulePegText, position36)
+				}
+				if !rules[Rules]() {
+					goto l34
+				}
+				if !rules[RuleAction6]() {
+					goto l34
+				}
+				depth--
+				add(RuleSort, position35)
+			}
+			return true
+		l34:
+			position, tokenIndex, depth = position34, tokenIndex34, depth34
+			return false
+		},
+		/* 10 Params <- <(CountersAttrs / Attrs)> */
+		func() bool {
+			position39, tokenIndex39, depth39 := position, tokenIndex, depth
+			{
+				position40 := position
+				depth++
+				{
+					position41, tokenIndex41, depth41 := position, tokenIndex, depth
+					if !rules[RuleCountersAttrs]() {
 						goto l42
 					}
-					if !rules[RuleAttr]() {
-						goto l42
+					goto l41
+				l42:
+					position, tokenIndex, depth = position41, tokenIndex41, depth41
+					if !rules[RuleAttrs]() {
+						goto l39
 					}
-					{
-						position43, tokenIndex43, depth43 := position, tokenIndex, depth
-						if !rules[Rules]() {
-							goto l43
-						}
-						goto l44
-					l43:
-						position, tokenIndex, depth = position43, tokenIndex43, depth43
+				}
+			l41:
+				depth--
+				add(RuleParams, position40)
+			}
+			return true
+		l39:
+			position, tokenIndex, depth = position39, tokenIndex39, depth39
+			return false
+		},
+		/* 11 CountersAttrs <- <((Counter CountersAttrs Action7) / Attrs)> */
+		func() bool {
+			position43, tokenIndex43, depth43 := position, tokenIndex, depth
+			{
+				position44 := position
+				depth++
+				{
+					position45, tokenIndex45, depth45 := position, tokenIndex, depth
+					if !rules[RuleCounter]() {
+						goto l46
 					}
-				l44:
-					goto l36
-				l42:
-					position, tokenIndex, depth = position36, tokenIndex36, depth36
-					{
-						position45, tokenIndex45, depth45 := position, tokenIndex, depth
-						if !rules[Rules]() {
-							goto l45
-						}
+					if !rules[RuleCountersAttrs]() {
+						goto l46
+					}
+					if !rules[RuleAction7]() {
 						goto l46
-					l45:
-						position, tokenIndex, depth = position45, tokenIndex45, depth45
 					}
+					goto l45
 				l46:
-					if !rules[RuleAction8]() {
-						goto l34
+					position, tokenIndex, depth = position45, tokenIndex45, depth45
+					if !rules[RuleAttrs]() {
+						goto l43
 					}
 				}
-			l36:
+			l45:
 				depth--
-				add(RuleAttrs, position35)
+				add(RuleCountersAttrs, position44)
 			}
 			return true
-		l34:
-			position, tokenIndex, depth = position34, tokenIndex34, depth34
+		l43:
+			position, tokenIndex, depth = position43, tokenIndex43, depth43
 			return false
 		},
-		/* 11 Attr <- <(AttrUnion / Attribute)> */
+		/* 12 Counter <- <(CountAll / CountBy)> */
 		func() bool {
 			position47, tokenIndex47, depth47 := position, tokenIndex, depth
 			{
@@ -1194,398 +1245,1050 @@ func (p *Parser) Init() {
 				depth++
 				{
 					position49, tokenIndex49, depth49 := position, tokenIndex, depth
-					if !rules[RuleAttrUnion]() {
+					if !rules[RuleCountAll]() {
 						goto l50
 					}
 					goto l49
 				l50:
 					position, tokenIndex, depth = position49, tokenIndex49, depth49
-					if !rules[RuleAttribute]() {
+					if !rules[RuleCountBy]() {
 						goto l47
 					}
 				}
 			l49:
 				depth--
-				add(RuleAttr, position48)
+				add(RuleCounter, position48)
 			}
 			return true
 		l47:
 			position, tokenIndex, depth = position47, tokenIndex47, depth47
 			return false
 		},
-		/* 12 Attribute <- <(<(attr_name ':' attr_value)> Action9)> */
+		/* 13 CountAll <- <('c' 'o' 'u' 'n' 't' '_' 'a' 'l' 'l' '(' <counter_name> ')' s Action8)> */
 		func() bool {
 			position51, tokenIndex51, depth51 := position, tokenIndex, depth
 			{
 				position52 := position
 				depth++
+				if buffer[position] != rune('c') {
+					goto l51
+				}
+				position++
+				if buffer[position] != rune('o') {
+					goto l51
+				}
+				position++
+				if buffer[position] != rune('u') {
+					goto l51
+				}
+				position++
+				if buffer[position] != rune('n') {
+					goto l51
+				}
+				position++
+				if buffer[position] != rune('t') {
+					goto l51
+				}
+				position++
+				if buffer[position] != rune('_') {
+					goto l51
+				}
+				position++
+				if buffer[position] != rune('a') {
+					goto l51
+				}
+				position++
+				if buffer[position] != rune('l') {
+					goto l51
+				}
+				position++
+				if buffer[position] != rune('l') {
+					goto l51
+				}
+				position++
+				if buffer[position] != rune('(') {
+					goto l51
+				}
+				position++
 				{
 					position53 := position
 					depth++
-					if !rules[Ruleattr_name]() {
-						goto l51
-					}
-					if buffer[position] != rune(':') {
-						goto l51
-					}
-					position++
-					if !rules[Ruleattr_value]() {
+					if !rules[Rulecounter_name]() {
 						goto l51
 					}
 					depth--
 					add(RulePegText, position53)
 				}
-				if !rules[RuleAction9]() {
+				if buffer[position] != rune(')') {
+					goto l51
+				}
+				position++
+				if !rules[Rules]() {
+					goto l51
+				}
+				if !rules[RuleAction8]() {
 					goto l51
 				}
 				depth--
-				add(RuleAttribute, position52)
+				add(RuleCountAll, position52)
 			}
 			return true
 		l51:
 			position, tokenIndex, depth = position51, tokenIndex51, depth51
 			return false
 		},
-		/* 13 AttrUnion <- <(Action10 AttributeORList Action11)> */
+		/* 14 CountBy <- <('c' 'o' 'u' 'n' 't' '_' 'b' 'y' '(' <(counter_name ',' ' '* attr_name)> ')' s Action9)> */
 		func() bool {
 			position54, tokenIndex54, depth54 := position, tokenIndex, depth
 			{
 				position55 := position
 				depth++
-				if !rules[RuleAction10]() {
+				if buffer[position] != rune('c') {
 					goto l54
 				}
-				if !rules[RuleAttributeORList]() {
+				position++
+				if buffer[position] != rune('o') {
 					goto l54
 				}
-				if !rules[RuleAction11]() {
+				position++
+				if buffer[position] != rune('u') {
 					goto l54
 				}
-				depth--
-				add(RuleAttrUnion, position55)
-			}
-			return true
-		l54:
-			position, tokenIndex, depth = position54, tokenIndex54, depth54
-			return false
-		},
-		/* 14 AttributeORList <- <(Attribute (s ('O' 'R') s Attribute)+)> */
-		func() bool {
-			position56, tokenIndex56, depth56 := position, tokenIndex, depth
-			{
-				position57 := position
-				depth++
-				if !rules[RuleAttribute]() {
-					goto l56
+				position++
+				if buffer[position] != rune('n') {
+					goto l54
 				}
-				if !rules[Rules]() {
-					goto l56
+				position++
+				if buffer[position] != rune('t') {
+					goto l54
 				}
-				if buffer[position] != rune('O') {
-					goto l56
+				position++
+				if buffer[position] != rune('_') {
+					goto l54
 				}
 				position++
-				if buffer[position] != rune('R') {
-					goto l56
+				if buffer[position] != rune('b') {
+					goto l54
 				}
 				position++
-				if !rules[Rules]() {
-					goto l56
+				if buffer[position] != rune('y') {
+					goto l54
 				}
-				if !rules[RuleAttribute]() {
-					goto l56
+				position++
+				if buffer[position] != rune('(') {
+					goto l54
 				}
-			l58:
+				position++
 				{
-					position59, tokenIndex59, depth59 := position, tokenIndex, depth
-					if !rules[Rules]() {
-						goto l59
+					position56 := position
+					depth++
+					if !rules[Rulecounter_name]() {
+						goto l54
 					}
-					if buffer[position] != rune('O') {
-						goto l59
+					if buffer[position] != rune(',') {
+						goto l54
 					}
 					position++
-					if buffer[position] != rune('R') {
-						goto l59
+				l57:
+					{
+						position58, tokenIndex58, depth58 := position, tokenIndex, depth
+						if buffer[position] != rune(' ') {
+							goto l58
+						}
+						position++
+						goto l57
+					l58:
+						position, tokenIndex, depth = position58, tokenIndex58, depth58
+					}
+					if !rules[Ruleattr_name]() {
+						goto l54
+					}
+					depth--
+					add(RulePegText, position56)
+				}
+				if buffer[position] != rune(')') {
+					goto l54
+				}
+				position++
+				if !rules[Rules]() {
+					goto l54
+				}
+				if !rules[RuleAction9]() {
+					goto l54
+				}
+				depth--
+				add(RuleCountBy, position55)
+			}
+			return true
+		l54:
+			position, tokenIndex, depth = position54, tokenIndex54, depth54
+			return false
+		},
+		/* 15 Attrs <- <(Action10 AttrList? Keywords?)> */
+		func() bool {
+			position59, tokenIndex59, depth59 := position, tokenIndex, depth
+			{
+				position60 := position
+				depth++
+				if !rules[RuleAction10]() {
+					goto l59
+				}
+				{
+					position61, tokenIndex61, depth61 := position, tokenIndex, depth
+					if !rules[RuleAttrList]() {
+						goto l61
+					}
+					goto l62
+				l61:
+					position, tokenIndex, depth = position61, tokenIndex61, depth61
+				}
+			l62:
+				{
+					position63, tokenIndex63, depth63 := position, tokenIndex, depth
+					if !rules[RuleKeywords]() {
+						goto l63
+					}
+					goto l64
+				l63:
+					position, tokenIndex, depth = position63, tokenIndex63, depth63
+				}
+			l64:
+				depth--
+				add(RuleAttrs, position60)
+			}
+			return true
+		l59:
+			position, tokenIndex, depth = position59, tokenIndex59, depth59
+			return false
+		},
+		/* 16 AttrList <- <(Attr (s Attr)* s?)> */
+		func() bool {
+			position65, tokenIndex65, depth65 := position, tokenIndex, depth
+			{
+				position66 := position
+				depth++
+				if !rules[RuleAttr]() {
+					goto l65
+				}
+			l67:
+				{
+					position68, tokenIndex68, depth68 := position, tokenIndex, depth
+					if !rules[Rules]() {
+						goto l68
+					}
+					if !rules[RuleAttr]() {
+						goto l68
+					}
+					goto l67
+				l68:
+					position, tokenIndex, depth = position68, tokenIndex68, depth68
+				}
+				{
+					position69, tokenIndex69, depth69 := position, tokenIndex, depth
+					if !rules[Rules]() {
+						goto l69
+					}
+					goto l70
+				l69:
+					position, tokenIndex, depth = position69, tokenIndex69, depth69
+				}
+			l70:
+				depth--
+				add(RuleAttrList, position66)
+			}
+			return true
+		l65:
+			position, tokenIndex, depth = position65, tokenIndex65, depth65
+			return false
+		},
+		/* 17 Attr <- <(NotAttr / AttrUnion / Range / Attribute)> */
+		func() bool {
+			position71, tokenIndex71, depth71 := position, tokenIndex, depth
+			{
+				position72 := position
+				depth++
+				{
+					position73, tokenIndex73, depth73 := position, tokenIndex, depth
+					if !rules[RuleNotAttr]() {
+						goto l74
+					}
+					goto l73
+				l74:
+					position, tokenIndex, depth = position73, tokenIndex73, depth73
+					if !rules[RuleAttrUnion]() {
+						goto l75
+					}
+					goto l73
+				l75:
+					position, tokenIndex, depth = position73, tokenIndex73, depth73
+					if !rules[RuleRange]() {
+						goto l76
+					}
+					goto l73
+				l76:
+					position, tokenIndex, depth = position73, tokenIndex73, depth73
+					if !rules[RuleAttribute]() {
+						goto l71
+					}
+				}
+			l73:
+				depth--
+				add(RuleAttr, position72)
+			}
+			return true
+		l71:
+			position, tokenIndex, depth = position71, tokenIndex71, depth71
+			return false
+		},
+		/* 18 Attribute <- <(<(attr_name ':' attr_value)> Action11)> */
+		func() bool {
+			position77, tokenIndex77, depth77 := position, tokenIndex, depth
+			{
+				position78 := position
+				depth++
+				{
+					position79 := position
+					depth++
+					if !rules[Ruleattr_name]() {
+						goto l77
+					}
+					if buffer[position] != rune(':') {
+						goto l77
 					}
 					position++
+					if !rules[Ruleattr_value]() {
+						goto l77
+					}
+					depth--
+					add(RulePegText, position79)
+				}
+				if !rules[RuleAction11]() {
+					goto l77
+				}
+				depth--
+				add(RuleAttribute, position78)
+			}
+			return true
+		l77:
+			position, tokenIndex, depth = position77, tokenIndex77, depth77
+			return false
+		},
+		/* 19 AttrUnion <- <(Action12 AttributeORList Action13)> */
+		func() bool {
+			position80, tokenIndex80, depth80 := position, tokenIndex, depth
+			{
+				position81 := position
+				depth++
+				if !rules[RuleAction12]() {
+					goto l80
+				}
+				if !rules[RuleAttributeORList]() {
+					goto l80
+				}
+				if !rules[RuleAction13]() {
+					goto l80
+				}
+				depth--
+				add(RuleAttrUnion, position81)
+			}
+			return true
+		l80:
+			position, tokenIndex, depth = position80, tokenIndex80, depth80
+			return false
+		},
+		/* 20 AttributeORList <- <(Attribute (s ('O' 'R') s Attribute)+)> */
+		func() bool {
+			position82, tokenIndex82, depth82 := position, tokenIndex, depth
+			{
+				position83 := position
+				depth++
+				if !rules[RuleAttribute]() {
+					goto l82
+				}
+				if !rules[Rules]() {
+					goto l82
+				}
+				if buffer[position] != rune('O') {
+					goto l82
+				}
+				position++
+				if buffer[position] != rune('R') {
+					goto l82
+				}
+				position++
+				if !rules[Rules]() {
+					goto l82
+				}
+				if !rules[RuleAttribute]() {
+					goto l82
+				}
+			l84:
+				{
+					position85, tokenIndex85, depth85 := position, tokenIndex, depth
 					if !rules[Rules]() {
-						goto l59
+						goto l85
+					}
+					if buffer[position] != rune('O') {
+						goto l85
+					}
+					position++
+					if buffer[position] != rune('R') {
+						goto l85
+					}
+					position++
+					if !rules[Rules]() {
+						goto l85
 					}
 					if !rules[RuleAttribute]() {
-						goto l59
+						goto l85
+					}
+					goto l84
+				l85:
+					position, tokenIndex, depth = position85, tokenIndex85, depth85
+				}
+				depth--
+				add(RuleAttributeORList, position83)
+			}
+			return true
+		l82:
+			position, tokenIndex, depth = position82, tokenIndex82, depth82
+			return false
+		},
+		/* 21 Range <- <(<(attr_name ':' number '-' number)> Action14)> */
+		func() bool {
+			position86, tokenIndex86, depth86 := position, tokenIndex, depth
+			{
+				position87 := position
+				depth++
+				{
+					position88 := position
+					depth++
+					if !rules[Ruleattr_name]() {
+						goto l86
+					}
+					if buffer[position] != rune(':') {
+						goto l86
+					}
+					position++
+					if !rules[Rulenumber]() {
+						goto l86
+					}
+					if buffer[position] != rune('-') {
+						goto l86
+					}
+					position++
+					if !rules[Rulenumber]() {
+						goto l86
+					}
+					depth--
+					add(RulePegText, position88)
+				}
+				if !rules[RuleAction14]() {
+					goto l86
+				}
+				depth--
+				add(RuleRange, position87)
+			}
+			return true
+		l86:
+			position, tokenIndex, depth = position86, tokenIndex86, depth86
+			return false
+		},
+		/* 22 NotAttr <- <(('-' / ('N' 'O' 'T' s)) Action15 Attribute Action16)> */
+		func() bool {
+			position89, tokenIndex89, depth89 := position, tokenIndex, depth
+			{
+				position90 := position
+				depth++
+				{
+					position91, tokenIndex91, depth91 := position, tokenIndex, depth
+					if buffer[position] != rune('-') {
+						goto l92
+					}
+					position++
+					goto l91
+				l92:
+					position, tokenIndex, depth = position91, tokenIndex91, depth91
+					if buffer[position] != rune('N') {
+						goto l89
+					}
+					position++
+					if buffer[position] != rune('O') {
+						goto l89
+					}
+					position++
+					if buffer[position] != rune('T') {
+						goto l89
+					}
+					position++
+					if !rules[Rules]() {
+						goto l89
+					}
+				}
+			l91:
+				if !rules[RuleAction15]() {
+					goto l89
+				}
+				if !rules[RuleAttribute]() {
+					goto l89
+				}
+				if !rules[RuleAction16]() {
+					goto l89
+				}
+				depth--
+				add(RuleNotAttr, position90)
+			}
+			return true
+		l89:
+			position, tokenIndex, depth = position89, tokenIndex89, depth89
+			return false
+		},
+		/* 23 Keywords <- <('*' ':' '*' (s Keyword)* s?)> */
+		func() bool {
+			position93, tokenIndex93, depth93 := position, tokenIndex, depth
+			{
+				position94 := position
+				depth++
+				if buffer[position] != rune('*') {
+					goto l93
+				}
+				position++
+				if buffer[position] != rune(':') {
+					goto l93
+				}
+				position++
+				if buffer[position] != rune('*') {
+					goto l93
+				}
+				position++
+			l95:
+				{
+					position96, tokenIndex96, depth96 := position, tokenIndex, depth
+					if !rules[Rules]() {
+						goto l96
+					}
+					if !rules[RuleKeyword]() {
+						goto l96
+					}
+					goto l95
+				l96:
+					position, tokenIndex, depth = position96, tokenIndex96, depth96
+				}
+				{
+					position97, tokenIndex97, depth97 := position, tokenIndex, depth
+					if !rules[Rules]() {
+						goto l97
+					}
+					goto l98
+				l97:
+					position, tokenIndex, depth = position97, tokenIndex97, depth97
+				}
+			l98:
+				depth--
+				add(RuleKeywords, position94)
+			}
+			return true
+		l93:
+			position, tokenIndex, depth = position93, tokenIndex93, depth93
+			return false
+		},
+		/* 24 Keyword <- <(Phrase / Word)> */
+		func() bool {
+			position99, tokenIndex99, depth99 := position, tokenIndex, depth
+			{
+				position100 := position
+				depth++
+				{
+					position101, tokenIndex101, depth101 := position, tokenIndex, depth
+					if !rules[RulePhrase]() {
+						goto l102
+					}
+					goto l101
+				l102:
+					position, tokenIndex, depth = position101, tokenIndex101, depth101
+					if !rules[RuleWord]() {
+						goto l99
 					}
-					goto l58
-				l59:
-					position, tokenIndex, depth = position59, tokenIndex59, depth59
 				}
+			l101:
 				depth--
-				add(RuleAttributeORList, position57)
+				add(RuleKeyword, position100)
 			}
 			return true
-		l56:
-			position, tokenIndex, depth = position56, tokenIndex56, depth56
+		l99:
+			position, tokenIndex, depth = position99, tokenIndex99, depth99
 			return false
 		},
-		/* 15 number <- <[0-9]+> */
+		/* 25 Word <- <(<word> Action17)> */
 		func() bool {
-			position60, tokenIndex60, depth60 := position, tokenIndex, depth
+			position103, tokenIndex103, depth103 := position, tokenIndex, depth
 			{
-				position61 := position
+				position104 := position
+				depth++
+				{
+					position105 := position
+					depth++
+					if !rules[Ruleword]() {
+						goto l103
+					}
+					depth--
+					add(RulePegText, position105)
+				}
+				if !rules[RuleAction17]() {
+					goto l103
+				}
+				depth--
+				add(RuleWord, position104)
+			}
+			return true
+		l103:
+			position, tokenIndex, depth = position103, tokenIndex103, depth103
+			return false
+		},
+		/* 26 Phrase <- <('"' s? <(word (s word)*)> s? '"' Action18)> */
+		func() bool {
+			position106, tokenIndex106, depth106 := position, tokenIndex, depth
+			{
+				position107 := position
+				depth++
+				if buffer[position] != rune('"') {
+					goto l106
+				}
+				position++
+				{
+					position108, tokenIndex108, depth108 := position, tokenIndex, depth
+					if !rules[Rules]() {
+						goto l108
+					}
+					goto l109
+				l108:
+					position, tokenIndex, depth = position108, tokenIndex108, depth108
+				}
+			l109:
+				{
+					position110 := position
+					depth++
+					if !rules[Ruleword]() {
+						goto l106
+					}
+				l111:
+					{
+						position112, tokenIndex112, depth112 := position, tokenIndex, depth
+						if !rules[Rules]() {
+							goto l112
+						}
+						if !rules[Ruleword]() {
+							goto l112
+						}
+						goto l111
+					l112:
+						position, tokenIndex, depth = position112, tokenIndex112, depth112
+					}
+					depth--
+					add(RulePegText, position110)
+				}
+				{
+					position113, tokenIndex113, depth113 := position, tokenIndex, depth
+					if !rules[Rules]() {
+						goto l113
+					}
+					goto l114
+				l113:
+					position, tokenIndex, depth = position113, tokenIndex113, depth113
+				}
+			l114:
+				if buffer[position] != rune('"') {
+					goto l106
+				}
+				position++
+				if !rules[RuleAction18]() {
+					goto l106
+				}
+				depth--
+				add(RulePhrase, position107)
+			}
+			return true
+		l106:
+			position, tokenIndex, depth = position106, tokenIndex106, depth106
+			return false
+		},
+		/* 27 number <- <[0-9]+> */
+		func() bool {
+			position115, tokenIndex115, depth115 := position, tokenIndex, depth
+			{
+				position116 := position
 				depth++
 				if c := buffer[position]; c < rune('0') || c > rune('9') {
-					goto l60
+					goto l115
 				}
 				position++
-			l62:
+			l117:
 				{
-					position63, tokenIndex63, depth63 := position, tokenIndex, depth
+					position118, tokenIndex118, depth118 := position, tokenIndex, depth
 					if c := buffer[position]; c < rune('0') || c > rune('9') {
-						goto l63
+						goto l118
 					}
 					position++
-					goto l62
-				l63:
-					position, tokenIndex, depth = position63, tokenIndex63, depth63
+					goto l117
+				l118:
+					position, tokenIndex, depth = position118, tokenIndex118, depth118
 				}
 				depth--
-				add(Rulenumber, position61)
+				add(Rulenumber, position116)
 			}
 			return true
-		l60:
-			position, tokenIndex, depth = position60, tokenIndex60, depth60
+		l115:
+			position, tokenIndex, depth = position115, tokenIndex115, depth115
 			return false
 		},
-		/* 16 s <- <' '+> */
+		/* 28 s <- <' '+> */
 		func() bool {
-			position64, tokenIndex64, depth64 := position, tokenIndex, depth
+			position119, tokenIndex119, depth119 := position, tokenIndex, depth
 			{
-				position65 := position
+				position120 := position
 				depth++
 				if buffer[position] != rune(' ') {
-					goto l64
+					goto l119
 				}
 				position++
-			l66:
+			l121:
 				{
-					position67, tokenIndex67, depth67 := position, tokenIndex, depth
+					position122, tokenIndex122, depth122 := position, tokenIndex, depth
 					if buffer[position] != rune(' ') {
-						goto l67
+						goto l122
 					}
 					position++
-					goto l66
-				l67:
-					position, tokenIndex, depth = position67, tokenIndex67, depth67
+					goto l121
+				l122:
+					position, tokenIndex, depth = position122, tokenIndex122, depth122
 				}
 				depth--
-				add(Rules, position65)
+				add(Rules, position120)
 			}
 			return true
-		l64:
-			position, tokenIndex, depth = position64, tokenIndex64, depth64
+		l119:
+			position, tokenIndex, depth = position119, tokenIndex119, depth119
 			return false
 		},
-		/* 17 counter_name <- <generic_name> */
+		/* 29 counter_name <- <generic_name> */
 		func() bool {
-			position68, tokenIndex68, depth68 := position, tokenIndex, depth
+			position123, tokenIndex123, depth123 := position, tokenIndex, depth
 			{
-				position69 := position
+				position124 := position
 				depth++
 				if !rules[Rulegeneric_name]() {
-					goto l68
+					goto l123
 				}
 				depth--
-				add(Rulecounter_name, position69)
+				add(Rulecounter_name, position124)
 			}
 			return true
-		l68:
-			position, tokenIndex, depth = position68, tokenIndex68, depth68
+		l123:
+			position, tokenIndex, depth = position123, tokenIndex123, depth123
 			return false
 		},
-		/* 18 attr_name <- <generic_name> */
+		/* 30 attr_name <- <generic_name> */
 		func() bool {
-			position70, tokenIndex70, depth70 := position, tokenIndex, depth
+			position125, tokenIndex125, depth125 := position, tokenIndex, depth
 			{
-				position71 := position
+				position126 := position
 				depth++
 				if !rules[Rulegeneric_name]() {
-					goto l70
+					goto l125
 				}
 				depth--
-				add(Ruleattr_name, position71)
+				add(Ruleattr_name, position126)
 			}
 			return true
-		l70:
-			position, tokenIndex, depth = position70, tokenIndex70, depth70
+		l125:
+			position, tokenIndex, depth = position125, tokenIndex125, depth125
 			return false
 		},
-		/* 19 attr_value <- <generic_name> */
+		/* 31 attr_value <- <generic_name> */
 		func() bool {
-			position72, tokenIndex72, depth72 := position, tokenIndex, depth
+			position127, tokenIndex127, depth127 := position, tokenIndex, depth
 			{
-				position73 := position
+				position128 := position
 				depth++
 				if !rules[Rulegeneric_name]() {
-					goto l72
+					goto l127
 				}
 				depth--
-				add(Ruleattr_value, position73)
+				add(Ruleattr_value, position128)
 			}
 			return true
-		l72:
-			position, tokenIndex, depth = position72, tokenIndex72, depth72
+		l127:
+			position, tokenIndex, depth = position127, tokenIndex127, depth127
 			return false
 		},
-		/* 20 generic_name <- <([a-z] / [0-9] / '_')+> */
+		/* 32 generic_name <- <([a-z] / [0-9] / '_')+> */
 		func() bool {
-			position74, tokenIndex74, depth74 := position, tokenIndex, depth
+			position129, tokenIndex129, depth129 := position, tokenIndex, depth
 			{
-				position75 := position
+				position130 := position
 				depth++
 				{
-					position78, tokenIndex78, depth78 := position, tokenIndex, depth
+					position133, tokenIndex133, depth133 := position, tokenIndex, depth
 					if c := buffer[position]; c < rune('a') || c > rune('z') {
-						goto l79
+						goto l134
 					}
 					position++
-					goto l78
-				l79:
-					position, tokenIndex, depth = position78, tokenIndex78, depth78
+					goto l133
+				l134:
+					position, tokenIndex, depth = position133, tokenIndex133, depth133
 					if c := buffer[position]; c < rune('0') || c > rune('9') {
-						goto l80
+						goto l135
 					}
 					position++
-					goto l78
-				l80:
-					position, tokenIndex, depth = position78, tokenIndex78, depth78
+					goto l133
+				l135:
+					position, tokenIndex, depth = position133, tokenIndex133, depth133
 					if buffer[position] != rune('_') {
-						goto l74
+						goto l129
 					}
 					position++
 				}
-			l78:
-			l76:
+			l133:
+			l131:
 				{
-					position77, tokenIndex77, depth77 := position, tokenIndex, depth
+					position132, tokenIndex132, depth132 := position, tokenIndex, depth
 					{
-						position81, tokenIndex81, depth81 := position, tokenIndex, depth
+						position136, tokenIndex136, depth136 := position, tokenIndex, depth
 						if c := buffer[position]; c < rune('a') || c > rune('z') {
-							goto l82
+							goto l137
 						}
 						position++
-						goto l81
-					l82:
-						position, tokenIndex, depth = position81, tokenIndex81, depth81
+						goto l136
+					l137:
+						position, tokenIndex, depth = position136, tokenIndex136, depth136
 						if c := buffer[position]; c < rune('0') || c > rune('9') {
-							goto l83
+							goto l138
 						}
 						position++
-						goto l81
-					l83:
-						position, tokenIndex, depth = position81, tokenIndex81, depth81
+						goto l136
+					l138:
+						position, tokenIndex, depth = position136, tokenIndex136, depth136
 						if buffer[position] != rune('_') {
-							goto l77
+							goto l132
 						}
 						position++
 					}
-				l81:
-					goto l76
-				l77:
-					position, tokenIndex, depth = position77, tokenIndex77, depth77
+				l136:
+					goto l131
+				l132:
+					position, tokenIndex, depth = position132, tokenIndex132, depth132
 				}
 				depth--
-				add(Rulegeneric_name, position75)
+				add(Rulegeneric_name, position130)
 			}
 			return true
-		l74:
-			position, tokenIndex, depth = position74, tokenIndex74, depth74
+		l129:
+			position, tokenIndex, depth = position129, tokenIndex129, depth129
 			return false
 		},
-		/* 22 Action0 <- <{ p.Pa() }> */
+		/* 33 word <- <(!('"' / ' ') .)+> */
+		func() bool {
+			position139, tokenIndex139, depth139 := position, tokenIndex, depth
+			{
+				position140 := position
+				depth++
+				{
+					position143, tokenIndex143, depth143 := position, tokenIndex, depth
+					{
+						position144, tokenIndex144, depth144 := position, tokenIndex, depth
+						if buffer[position] != rune('"') {
+							goto l145
+						}
+						position++
+						goto l144
+					l145:
+						position, tokenIndex, depth = position144, tokenIndex144, depth144
+						if buffer[position] != rune(' ') {
+							goto l143
+						}
+						position++
+					}
+				l144:
+					goto l139
+				l143:
+					position, tokenIndex, depth = position143, tokenIndex143, depth143
+				}
+				if !matchDot() {
+					goto l139
+				}
+			l141:
+				{
+					position142, tokenIndex142, depth142 := position, tokenIndex, depth
+					{
+						position146, tokenIndex146, depth146 := position, tokenIndex, depth
+						{
+							position147, tokenIndex147, depth147 := position, tokenIndex, depth
+							if buffer[position] != rune('"') {
+								goto l148
+							}
+							position++
+							goto l147
+						l148:
+							position, tokenIndex, depth = position147, tokenIndex147, depth147
+							if buffer[position] != rune(' ') {
+								goto l146
+							}
+							position++
+						}
+					l147:
+						goto l142
+					l146:
+						position, tokenIndex, depth = position146, tokenIndex146, depth146
+					}
+					if !matchDot() {
+						goto l142
+					}
+					goto l141
+				l142:
+					position, tokenIndex, depth = position142, tokenIndex142, depth142
+				}
+				depth--
+				add(Ruleword, position140)
+			}
+			return true
+		l139:
+			position, tokenIndex, depth = position139, tokenIndex139, depth139
+			return false
+		},
+		/* 35 Action0 <- <{ p.Pa() }> */
 		func() bool {
 			{
 				add(RuleAction0, position)
 			}
 			return true
 		},
-		/* 23 Action1 <- <{ p.Pa() }> */
+		/* 36 Action1 <- <{ p.Pa() }> */
 		func() bool {
 			{
 				add(RuleAction1, position)
 			}
 			return true
 		},
-		nil,
-		/* 25 Action2 <- <{ p.Off(buffer[begin:end]) }> */
+		/* 37 Action2 <- <{ p.Pa() }> */
 		func() bool {
 			{
 				add(RuleAction2, position)
 			}
 			return true
 		},
-		/* 26 Action3 <- <{ p.Lim(buffer[begin:end]) }> */
+		nil,
+		/* 39 Action3 <- <{ p.Off(string([]rune(buffer)[begin:end])) }> */
 		func() bool {
 			{
 				add(RuleAction3, position)
 			}
 			return true
 		},
-		/* 27 Action4 <- <{ p.Pa() }> */
+		/* 40 Action4 <- <{ p.Lim(string([]rune(buffer)[begin:end])) }> */
 		func() bool {
 			{
 				add(RuleAction4, position)
 			}
 			return true
 		},
-		/* 28 Action5 <- <{ p.Countall(buffer[begin:end]) }> */
+		/* 41 Action5 <- <{ p.Rand(string([]rune(buffer)[begin:end])) }> */
 		func() bool {
 			{
 				add(RuleAction5, position)
 			}
 			return true
 		},
-		/* 29 Action6 <- <{ p.Inter() }> */
+		/* 42 Action6 <- <{ p.Sort(string([]rune(buffer)[begin:end])) }> */
 		func() bool {
 			{
 				add(RuleAction6, position)
 			}
 			return true
 		},
-		/* 30 Action7 <- <{ p.Inter(); fmt.Printf("one attribute\n") }> */
+		/* 43 Action7 <- <{ p.Pa() }> */
 		func() bool {
 			{
 				add(RuleAction7, position)
 			}
 			return true
 		},
-		/* 31 Action8 <- <{ fmt.Printf("no attributes\n") }> */
+		/* 44 Action8 <- <{ p.Countall(string([]rune(buffer)[begin:end])) }> */
 		func() bool {
 			{
 				add(RuleAction8, position)
 			}
 			return true
 		},
-		/* 32 Action9 <- <{ p.Attr(buffer[begin:end]) }> */
+		/* 45 Action9 <- <{ p.Countby(string([]rune(buffer)[begin:end])) }> */
 		func() bool {
 			{
 				add(RuleAction9, position)
 			}
 			return true
 		},
-		/* 33 Action10 <- <{ p.Union() }> */
+		/* 46 Action10 <- <{ p.Inter() }> */
 		func() bool {
 			{
 				add(RuleAction10, position)
 			}
 			return true
 		},
-		/* 34 Action11 <- <{ p.Pa() }> */
+		/* 47 Action11 <- <{ p.Attr(string([]rune(buffer)[begin:end])) }> */
 		func() bool {
 			{
 				add(RuleAction11, position)
 			}
 			return true
 		},
+		/* 48 Action12 <- <{ p.Union() }> */
+		func() bool {
+			{
+				add(RuleAction12, position)
+			}
+			return true
+		},
+		/* 49 Action13 <- <{ p.Pa() }> */
+		func() bool {
+			{
+				add(RuleAction13, position)
+			}
+			return true
+		},
+		/* 50 Action14 <- <{ p.Range(string([]rune(buffer)[begin:end])) }> */
+		func() bool {
+			{
+				add(RuleAction14, position)
+			}
+			return true
+		},
+		/* 51 Action15 <- <{ p.Not() }> */
+		func() bool {
+			{
+				add(RuleAction15, position)
+			}
+			return true
+		},
+		/* 52 Action16 <- <{ p.Pa() }> */
+		func() bool {
+			{
+				add(RuleAction16, position)
+			}
+			return true
+		},
+		/* 53 Action17 <- <{ p.Word(string([]rune(buffer)[begin:end])) }> */
+		func() bool {
+			{
+				add(RuleAction17, position)
+			}
+			return true
+		},
+		/* 54 Action18 <- <{ p.Phrase(string([]rune(buffer)[begin:end])) }> */
+		func() bool {
+			{
+				add(RuleAction18, position)
+			}
+			return true
+		},
 	}
 	p.rules = rules
 }
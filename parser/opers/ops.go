@@ -5,6 +5,7 @@ package opers
 
 import (
 	"strconv"
+	"strings"
 	"fmt"
 	"errors"
 )
@@ -18,6 +19,13 @@ const (
 	oOffset
 	oLimit
 	oCountAll
+	oWord
+	oPhrase
+	oNot
+	oRange
+	oCountBy
+	oRand
+	oSort
 )
 
 var nameTyp = map[string]optype {
@@ -27,6 +35,13 @@ var nameTyp = map[string]optype {
 	"offset": oOffset,
 	"limit": oLimit,
 	"count_all": oCountAll,
+	"word": oWord,
+	"phrase": oPhrase,
+	"not": oNot,
+	"range": oRange,
+	"count_by": oCountBy,
+	"rand": oRand,
+	"sort": oSort,
 }
 
 type valtype int
@@ -42,6 +57,8 @@ type opattrs struct {
 	valtyp valtype
 	hasname, hascontents bool
 	singlecontent bool
+	nvalues int
+	optvalue bool
 }
 
 var opAttr = map[optype]opattrs{
@@ -52,6 +69,13 @@ var opAttr = map[optype]opattrs{
 	oOffset: { name: "offset", valtyp: vtInt, hascontents: true, singlecontent: true },
 	oLimit: { name: "limit", valtyp: vtInt, hascontents: true, singlecontent: true },
 	oCountAll: { name: "count_all", hasname: true, hascontents: true, singlecontent: true },
+	oWord: { name: "word", hasname: true },
+	oPhrase: { name: "phrase", valtyp: vtString },
+	oNot: { name: "not", hascontents: true, singlecontent: true },
+	oRange: { name: "range", hasname: true, valtyp: vtInt, nvalues: 2 },
+	oCountBy: { name: "count_by", hasname: true, valtyp: vtString, nvalues: 1, hascontents: true, singlecontent: true },
+	oRand: { name: "rand", valtyp: vtInt, nvalues: 1, hascontents: true, singlecontent: true },
+	oSort: { name: "sort", hasname: true, valtyp: vtString, optvalue: true, hascontents: true, singlecontent: true },
 }
 
 type Op struct {
@@ -76,8 +100,15 @@ type Query struct {
 var ErrSyntax = errors.New("query syntax error")
 var ErrLimitRange = errors.New("limit out of range")
 var ErrOffsetRange = errors.New("offset out of range")
+var ErrAttrRange = errors.New("attribute range out of range")
+var ErrSeedRange = errors.New("random seed out of range")
+var ErrSortOrder = errors.New("sort order must be asc or desc")
+var ErrSortField = errors.New("no such sort field")
+var ErrCountField = errors.New("no such count_by field")
 // Used in Generate if Parse error not handled
 var ErrTyp = errors.New("invalid operation type")
+var ErrNot = errors.New("not is only allowed in an intersection")
+var ErrNotOnly = errors.New("an intersection needs something that isn't negated")
 
 func (q *Query) err(e error) {
 	q.Err = append(q.Err, e)
@@ -99,6 +130,23 @@ func (q *Query) Off(o string) {
 	q.push(&Op{ typ: oOffset, intValue: []int64{ oi } })
 }
 
+func (q *Query) Rand(s string) {
+	si, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		q.err(ErrSeedRange)
+	}
+	q.push(&Op{ typ: oRand, intValue: []int64{ si } })
+}
+
+// Sort takes a field name, prefixed with '-' for descending order.
+func (q *Query) Sort(s string) {
+	if s[0] == '-' {
+		q.push(&Op{ typ: oSort, name: s[1:], strValue: []string{ "desc" } })
+	} else {
+		q.push(&Op{ typ: oSort, name: s })
+	}
+}
+
 func (q *Query) Inter() {
 	q.push(&Op{ typ: oIntersection })
 }
@@ -111,10 +159,43 @@ func (q *Query) Countall(s string) {
 	q.push(&Op{ typ: oCountAll, name: s})
 }
 
+// Countby takes "counter_name,attr_name".
+func (q *Query) Countby(s string) {
+	i := strings.Index(s, ",")
+	q.push(&Op{ typ: oCountBy, name: s[:i], strValue: []string{ strings.TrimSpace(s[i+1:]) } })
+}
+
 func (q *Query) Attr(a string) {
 	q.Add(&Op{ typ: oAttr, name: a})		// split into name+value later.
 }
 
+// Range takes "name:low-high".
+func (q *Query) Range(r string) {
+	i := strings.Index(r, ":")
+	j := strings.LastIndex(r, "-")
+	low, err := strconv.ParseInt(r[i+1:j], 10, 64)
+	if err != nil {
+		q.err(ErrAttrRange)
+	}
+	high, err := strconv.ParseInt(r[j+1:], 10, 64)
+	if err != nil {
+		q.err(ErrAttrRange)
+	}
+	q.Add(&Op{ typ: oRange, name: r[:i], intValue: []int64{ low, high } })
+}
+
+func (q *Query) Not() {
+	q.push(&Op{ typ: oNot })
+}
+
+func (q *Query) Word(w string) {
+	q.Add(&Op{ typ: oWord, name: strings.ToLower(w)})
+}
+
+func (q *Query) Phrase(p string) {
+	q.Add(&Op{ typ: oPhrase, strValue: strings.Fields(strings.ToLower(p))})
+}
+
 func (q *Query) Pa() {
 	if len(q.Stack) > 1 {	// XXX - horrible workaround so that the top element doesn't pop.
 		q.Add(q.pop())
@@ -163,18 +244,23 @@ func (q *Query) OpEnd() {
 	if len(top.opValue) != 0 && oa.valtyp != vtOp {
 		q.err(errors.New(fmt.Sprintf("Op %v shouldn't have a opvalue: %v", oa.name, len(top.strValue))))
 	}
-	switch oa.valtyp {
-	case vtInt:
-		if len(top.intValue) == 0 {
-			q.err(errors.New(fmt.Sprintf("Op %v needs intvalue", oa.name)))
-		}
-	case vtString:
-		if len(top.strValue) == 0 {
-			q.err(errors.New(fmt.Sprintf("Op %v needs strvalue", oa.name)))
-		}
-	case vtOp:
-		if len(top.opValue) == 0 {
-			q.err(errors.New(fmt.Sprintf("Op %v needs opvalue", oa.name)))
+	if oa.nvalues != 0 && len(top.strValue) + len(top.intValue) + len(top.opValue) != oa.nvalues {
+		q.err(errors.New(fmt.Sprintf("Op %v needs %d values", oa.name, oa.nvalues)))
+	}
+	if !oa.optvalue {
+		switch oa.valtyp {
+		case vtInt:
+			if len(top.intValue) == 0 {
+				q.err(errors.New(fmt.Sprintf("Op %v needs intvalue", oa.name)))
+			}
+		case vtString:
+			if len(top.strValue) == 0 {
+				q.err(errors.New(fmt.Sprintf("Op %v needs strvalue", oa.name)))
+			}
+		case vtOp:
+			if len(top.opValue) == 0 {
+				q.err(errors.New(fmt.Sprintf("Op %v needs opvalue", oa.name)))
+			}
 		}
 	}
 	if len(q.Stack) > 0 {	// XXX - horrible workaround so that the top element doesn't pop.
@@ -184,9 +270,14 @@ func (q *Query) OpEnd() {
 	}
 }
 
+// OpName takes the name with its quotes.
 func (q *Query) OpName(name string) {
 	top := q.Stack[len(q.Stack)-1]
-	top.name = name
+	n, err := strconv.Unquote(name)
+	if err != nil {
+		q.err(fmt.Errorf("bad name %v: %v", name, err))
+	}
+	top.name = n
 }
 
 func (q *Query) OpIntValue(val string) {
@@ -199,11 +290,33 @@ func (q *Query) OpIntValue(val string) {
 	top.intValue = append(top.intValue, vi)
 }
 
+// OpStrValue takes a value that is either a plain name or quoted.
 func (q *Query) OpStrValue(val string) {
 	top := q.Stack[len(q.Stack)-1]
+	if strings.HasPrefix(val, `"`) {
+		v, err := strconv.Unquote(val)
+		if err != nil {
+			q.err(fmt.Errorf("bad value %v: %v", val, err))
+		}
+		val = v
+	}
 	top.strValue = append(top.strValue, val)
 }
 
+// quoteValue quotes values that the structured parser doesn't take
+// as they are.
+func quoteValue(v string) string {
+	if v == "" {
+		return strconv.Quote(v)
+	}
+	for _, c := range v {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == ':') {
+			return strconv.Quote(v)
+		}
+	}
+	return v
+}
+
 func (q *Query) push(o *Op) {
 	q.Stack = append(q.Stack, o)
 }
@@ -229,23 +342,27 @@ func (o Op) String() string {
 	case oOffset:	t = "offset"
 	case oLimit:	t = "limit"
 	case oCountAll:	t = "count_all"
+	case oWord:	t = "word"
+	case oPhrase:	t = "phrase"
+	case oNot:	t = "not"
+	case oRange:	t = "range"
+	case oCountBy:	t = "count_by"
+	case oRand:	t = "rand"
+	case oSort:	t = "sort"
 	}
 	s := "(" + t
 	if o.name != "" {
-		s += ` "` + o.name + `"`
+		s += " " + strconv.Quote(o.name)
 	}
-	var vs string
+	// One value per brackets, the same way the structured parser reads them.
 	for _, v := range o.strValue {
-		vs += ` "` + v + `"`
+		s += ` [ ` + quoteValue(v) + ` ]`
 	}
 	for _, v := range o.intValue {
-		vs += ` ` + fmt.Sprint(v)
+		s += ` [ ` + fmt.Sprint(v) + ` ]`
 	}
 	for _, v := range o.opValue {
-		vs += v.String()
-	}
-	if vs != "" {
-		s += ` [` + vs + ` ]`
+		s += ` [ ` + v.String() + ` ]`
 	}
 	for _, v := range o.contents {
 		s += " " + v.String()
@@ -253,3 +370,25 @@ func (o Op) String() string {
 	s += ")"
 	return s
 }
+
+// Check returns the errors in the query that the grammars don't catch.
+// There is no set of all documents to exclude from, so an intersection
+// of only negations like "-b:b" is rejected.
+func (o *Op) Check() []error {
+	var errs []error
+	if o.typ == oIntersection && len(o.contents) > 0 {
+		only := true
+		for _, c := range o.contents {
+			if c.typ != oNot {
+				only = false
+			}
+		}
+		if only {
+			errs = append(errs, ErrNotOnly)
+		}
+	}
+	for _, c := range o.contents {
+		errs = append(errs, c.Check()...)
+	}
+	return errs
+}
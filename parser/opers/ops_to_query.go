@@ -9,13 +9,52 @@ import (
 )
 
 func (o *Op) Generate(i *index.Index) (ops.QueryOp, []error) {
+	return o.generate(i, nil, nil)
+}
+
+// GenerateDeadline is Generate for a query that stops when dl expires.
+// The documents that sort and rand reorder are kept in rk, see
+// ops.Ranks.
+func (o *Op) GenerateDeadline(i *index.Index, dl *ops.Deadline, rk *ops.Ranks) (ops.QueryOp, []error) {
+	return o.generate(i, dl, rk)
+}
+
+func (o *Op) generate(i *index.Index, dl *ops.Deadline, rk *ops.Ranks) (ops.QueryOp, []error) {
 	var qc ops.QueryContainer
 
+	// The offset op skips documents through the limit under it, the
+	// limit has to count them too.
+	if o.typ == oOffset && len(o.contents) == 1 && o.contents[0].typ == oLimit {
+		l := *o.contents[0]
+		l.intValue = []int64{l.intValue[0] + o.intValue[0]}
+		oo := *o
+		oo.contents = []*Op{&l}
+		o = &oo
+	}
+
 	switch o.typ {
 	case oInvalid:
 		return nil, []error{ ErrTyp }
 	case oAttr:
-		return ops.NewAttr(i, o.name), nil
+		return dl.Wrap(ops.NewAttr(i, o.name)), nil
+	case oWord:
+		// Words the indexer splits, like "e-mail", are phrases.
+		switch ws := index.SplitWords(o.name); len(ws) {
+		case 0:
+			return dl.Wrap(ops.NewWord(i, o.name)), nil
+		case 1:
+			return dl.Wrap(ops.NewWord(i, ws[0])), nil
+		default:
+			return dl.Wrap(ops.NewPhrase(i, ws...)), nil
+		}
+	case oPhrase:
+		var ws []string
+		for _, w := range o.strValue {
+			ws = append(ws, index.SplitWords(w)...)
+		}
+		return dl.Wrap(ops.NewPhrase(i, ws...)), nil
+	case oRange:
+		return dl.Wrap(ops.NewRange(i, o.name, o.intValue[0], o.intValue[1])), nil
 	case oUnion:
 		qc = ops.NewUnion()
 	case oIntersection:
@@ -23,16 +62,65 @@ func (o *Op) Generate(i *index.Index) (ops.QueryOp, []error) {
 	case oOffset:
 		qc = ops.NewOffset(uint(o.intValue[0]))
 	case oLimit:
-		qc = ops.NewLimit(uint(o.intValue[0]))
+		if o.counts() {
+			qc = ops.NewLimitCounted(uint(o.intValue[0]))
+		} else {
+			qc = ops.NewLimit(uint(o.intValue[0]))
+		}
 	case oCountAll:
 		qc = ops.CountAll(o.name)
+	case oCountBy:
+		if i.Field(o.strValue[0]) == -1 {
+			return nil, []error{ ErrCountField }
+		}
+		qc = ops.CountBy(i, o.name, o.strValue[0])
+	case oRand:
+		qc = ops.NewRandom(o.intValue[0])
+	case oSort:
+		desc := false
+		if len(o.strValue) > 0 {
+			switch o.strValue[0] {
+			case "asc":
+			case "desc":
+				desc = true
+			default:
+				return nil, []error{ ErrSortOrder }
+			}
+		}
+		if i.Field(o.name) == -1 && o.name != "suborder" {
+			return nil, []error{ ErrSortField }
+		}
+		qc = ops.NewSort(i, o.name, desc)
+	case oNot:
+		return nil, []error{ ErrNot }
 	}
+	var excl []ops.QueryOp
 	for _, v := range o.contents {
-		c, err := v.Generate(i)
+		var c ops.QueryOp
+		var err []error
+		if v.typ == oNot && o.typ == oIntersection {
+			c, err = v.contents[0].generate(i, dl, rk)
+		} else {
+			c, err = v.generate(i, dl, rk)
+		}
 		if err != nil {
 			return nil, err
 		}
-		qc.Add(c)
+		if v.typ == oNot {
+			excl = append(excl, c)
+		} else {
+			qc.Add(c)
+		}
+	}
+	dl.Attach(qc)
+	rk.Attach(qc)
+	if excl != nil {
+		if len(excl) == len(o.contents) {
+			return nil, []error{ ErrNotOnly }
+		}
+		ex := ops.NewExclusion(qc, excl[0])
+		ex.Add(excl[1:]...)
+		return ex, nil
 	}
 	return qc, nil
 }
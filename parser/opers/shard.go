@@ -0,0 +1,65 @@
+// Copyright 2013 Artur Grabowski. All rights reserved.
+// Use of this source code is governed by a ISC-style
+// license that can be found in the LICENSE file.
+package opers
+
+import "errors"
+
+var ErrShardReorder = errors.New("sort and rand can't be used on sharded indexes or brokers, use an index that isn't sharded")
+
+// Sharded splits the offset and limit off the top of the query so that
+// the rest of it can run on every shard of an index and the offset and
+// limit applied to the merged hits. No shard can contribute more than
+// offset+limit hits to the result, so the query for the shards is
+// limited to that. The counters under the limit still count all the
+// documents of the shard. limit is -1 if the query has no limit.
+//
+// Sort and rand renumber the hits of each shard on their own, those
+// hits can't be merged so they are rejected.
+func (o *Op) Sharded() (sq *Op, offset, limit int64, err error) {
+	limit = -1
+loop:
+	for {
+		switch o.typ {
+		case oOffset:
+			offset = o.intValue[0]
+		case oLimit:
+			limit = o.intValue[0]
+		default:
+			break loop
+		}
+		o = o.contents[0]
+	}
+	if o.reorders() {
+		return nil, 0, 0, ErrShardReorder
+	}
+	if limit == -1 {
+		return o, offset, limit, nil
+	}
+	return &Op{typ: oLimit, intValue: []int64{offset + limit}, contents: []*Op{o}}, offset, limit, nil
+}
+
+// counts tells if there are counters in the query.
+func (o *Op) counts() bool {
+	if o.typ == oCountAll || o.typ == oCountBy {
+		return true
+	}
+	for _, c := range o.contents {
+		if c.counts() {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Op) reorders() bool {
+	if o.typ == oRand || o.typ == oSort {
+		return true
+	}
+	for _, c := range o.contents {
+		if c.reorders() {
+			return true
+		}
+	}
+	return false
+}
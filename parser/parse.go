@@ -24,6 +24,9 @@ func ParseClassic(s string) (*opers.Op, []error) {
 	if q.Err != nil {
 		return nil, q.Err
 	}
+	if errs := q.Stack[0].Check(); errs != nil {
+		return nil, errs
+	}
 
 	return q.Stack[0], nil
 }
@@ -53,6 +56,9 @@ func ParseStructured(s string, eet *timers.Event) (*opers.Op, []error) {
 	if q.Err != nil {
 		return nil, q.Err
 	}
+	if errs := q.Stack[0].Check(); errs != nil {
+		return nil, errs
+	}
 
 	return q.Stack[0], nil
 }
@@ -5,6 +5,7 @@ package parser_test
 
 import (
 	. "bsearch/parser"
+	"bsearch/parser/opers"
 	"testing"
 	"github.com/art4711/timers"
 )
@@ -37,4 +38,180 @@ func BenchmarkStructured(b *testing.B) {
 			b.Fatal(err)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestClassicKeywords(t *testing.T) {
+	ops, err := ParseClassic(`lim:10 a:a *:* foo "Red  bicycle"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ops.String()
+	if s != `(limit [ 10 ] (intersection (attr "a:a") (word "foo") (phrase [ red ] [ bicycle ])))` {
+		t.Errorf("wrong result: %v", s)
+	}
+}
+
+func TestStructuredPhrase(t *testing.T) {
+	q := `(intersection (word "foo") (phrase [ red ] [ bicycle ]))`
+	ops, err := ParseStructured(q, timers.New().Start("hej"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ops.String()
+	if s != q {
+		t.Errorf("wrong result: %v", s)
+	}
+}
+
+func TestClassicNot(t *testing.T) {
+	ops, err := ParseClassic("a:a -b:b NOT c:c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ops.String()
+	if s != `(intersection (attr "a:a") (not (attr "b:b")) (not (attr "c:c")))` {
+		t.Errorf("wrong result: %v", s)
+	}
+}
+
+func TestClassicRange(t *testing.T) {
+	ops, err := ParseClassic("a:a price:100-500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ops.String()
+	if s != `(intersection (attr "a:a") (range "price" [ 100 ] [ 500 ]))` {
+		t.Errorf("wrong result: %v", s)
+	}
+}
+
+func TestStructuredRange(t *testing.T) {
+	q := `(range "price" [ 100 ] [ 500 ])`
+	ops, err := ParseStructured(q, timers.New().Start("hej"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ops.String()
+	if s != q {
+		t.Errorf("wrong result: %v", s)
+	}
+	_, err = ParseStructured(`(range "price" [ 100 ])`, timers.New().Start("hej"))
+	if err == nil {
+		t.Errorf("range with one value accepted")
+	}
+}
+
+func TestClassicCountBy(t *testing.T) {
+	ops, err := ParseClassic("lim:10 count_all(all) count_by(hits, category) a:a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ops.String()
+	if s != `(limit [ 10 ] (count_all "all" (count_by "hits" [ category ] (intersection (attr "a:a")))))` {
+		t.Errorf("wrong result: %v", s)
+	}
+}
+
+func TestClassicRand(t *testing.T) {
+	ops, err := ParseClassic("10 lim:10 rand:4711 a:a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ops.String()
+	if s != `(offset [ 10 ] (limit [ 10 ] (rand [ 4711 ] (intersection (attr "a:a")))))` {
+		t.Errorf("wrong result: %v", s)
+	}
+}
+
+func TestClassicSort(t *testing.T) {
+	ops, err := ParseClassic("lim:10 sort:-price a:a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ops.String()
+	if s != `(limit [ 10 ] (sort "price" [ desc ] (intersection (attr "a:a"))))` {
+		t.Errorf("wrong result: %v", s)
+	}
+}
+
+func TestStructuredSort(t *testing.T) {
+	q := `(sort "suborder" (attr "a:a"))`
+	ops, err := ParseStructured(q, timers.New().Start("hej"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ops.String()
+	if s != q {
+		t.Errorf("wrong result: %v", s)
+	}
+}
+
+func TestSharded(t *testing.T) {
+	o, err := ParseClassic("17 lim:10 count_all(hejsan) a:a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sq, offset, limit, serr := o.Sharded()
+	if serr != nil {
+		t.Fatal(serr)
+	}
+	if offset != 17 || limit != 10 {
+		t.Errorf("wrong offset/limit: %v %v", offset, limit)
+	}
+	s := sq.String()
+	if s != `(limit [ 27 ] (count_all "hejsan" (intersection (attr "a:a"))))` {
+		t.Errorf("wrong result: %v", s)
+	}
+
+	o, err = ParseClassic("lim:10 sort:-price a:a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, serr := o.Sharded(); serr != opers.ErrShardReorder {
+		t.Errorf("sort not rejected: %v", serr)
+	}
+}
+
+// The structured form of classic queries parses back to the same query,
+// that is what brokers send to their backends.
+func TestRoundtrip(t *testing.T) {
+	for _, q := range []string{
+		"17 lim:10 count_all(hejsan) a:a b:a OR b:b",
+		`lim:10 a:a *:* foo "Red  bicycle"`,
+		"count_by(n, a) -b:b a:1-10 *:* foo",
+		"lim:10 sort:-price a:a",
+		"*:* e-mail",
+		"*:* café",
+		`*:* "it's ok"`,
+		`*:* a\b`,
+	} {
+		o, err := ParseClassic(q)
+		if err != nil {
+			t.Errorf("%q: %v", q, err)
+			continue
+		}
+		s := o.String()
+		so, err := ParseStructured(s, timers.New().Start("hej"))
+		if err != nil {
+			t.Errorf("%q: %v: %v", q, s, err)
+			continue
+		}
+		if ss := so.String(); ss != s {
+			t.Errorf("%q: %v != %v", q, ss, s)
+		}
+	}
+}
+
+func TestNotOnly(t *testing.T) {
+	for _, q := range []string{"-b:b", "lim:10 NOT b:b -c:c"} {
+		if _, errs := ParseClassic(q); len(errs) != 1 || errs[0] != opers.ErrNotOnly {
+			t.Errorf("%q: %v", q, errs)
+		}
+	}
+	_, errs := ParseStructured(`(union (attr "a:a") (intersection (not (attr "b:b"))))`, timers.New().Start("hej"))
+	if len(errs) != 1 || errs[0] != opers.ErrNotOnly {
+		t.Errorf("structured: %v", errs)
+	}
+	if _, errs := ParseClassic("a:a -b:b"); errs != nil {
+		t.Errorf("a:a -b:b: %v", errs)
+	}
+}
@@ -28,6 +28,7 @@ const (
 	Rules
 	Rulegeneric_name
 	Ruleopname
+	Rulequoted
 	RuleAction0
 	RulePegText
 	RuleAction1
@@ -53,6 +54,7 @@ var Rul3s = [...]string{
 	"s",
 	"generic_name",
 	"opname",
+	"quoted",
 	"Action0",
 	"PegText",
 	"Action1",
@@ -563,7 +565,7 @@ type Parser struct {
 
 	Buffer string
 	buffer []rune
-	rules  [18]func() bool
+	rules  [19]func() bool
 	TokenTree
 
 	tokenIndex int
@@ -644,13 +646,13 @@ func (p *Parser) Execute() {
 		case RuleAction0:
 			p.OpEnd()
 		case RuleAction1:
-			p.OpStart(buffer[begin:end])
+			p.OpStart(string([]rune(buffer)[begin:end]))
 		case RuleAction2:
-			p.OpName(buffer[begin:end])
+			p.OpName(string([]rune(buffer)[begin:end]))
 		case RuleAction3:
-			p.OpIntValue(buffer[begin:end])
+			p.OpIntValue(string([]rune(buffer)[begin:end]))
 		case RuleAction4:
-			p.OpStrValue(buffer[begin:end])
+			p.OpStrValue(string([]rune(buffer)[begin:end]))
 
 		}
 	}
@@ -728,6 +730,7 @@ func (p *Parser) Init() {
 		p.XRules,
 		p.XRulegeneric_name,
 		p.XRuleopname,
+		p.XRulequoted,
 		p.XRuleAction0,
 		nil,
 		p.XRuleAction1,
@@ -859,29 +862,21 @@ l11:
 	return false
 }
 
-/* 3 Name <- <('"' <generic_name> '"' Action2)> */
+/* 3 Name <- <(<quoted> Action2)> */
 func (p *Parser) XRuleName() bool {
 	position14, tokenIndex14, depth14 := p.position, p.tokenIndex, p.depth
 	{
 		position15 := p.position
 		p.depth++
-		if p.buffer[p.position] != rune('"') {
-			goto l14
-		}
-		p.position++
 		{
 			position16 := p.position
 			p.depth++
-			if !p.XRulegeneric_name() {
+			if !p.XRulequoted() {
 				goto l14
 			}
 			p.depth--
 			p.add(RulePegText, position16)
 		}
-		if p.buffer[p.position] != rune('"') {
-			goto l14
-		}
-		p.position++
 		if !p.XRuleAction2() {
 			goto l14
 		}
@@ -963,7 +958,7 @@ l21:
 	return false
 }
 
-/* 6 StrValue <- <('[' s <generic_name> s ']' Action4)> */
+/* 6 StrValue <- <('[' s <(quoted / generic_name)> s ']' Action4)> */
 func (p *Parser) XRuleStrValue() bool {
 	position24, tokenIndex24, depth24 := p.position, p.tokenIndex, p.depth
 	{
@@ -979,9 +974,19 @@ func (p *Parser) XRuleStrValue() bool {
 		{
 			position26 := p.position
 			p.depth++
-			if !p.XRulegeneric_name() {
-				goto l24
+			{
+				position56, tokenIndex56, depth56 := p.position, p.tokenIndex, p.depth
+				if !p.XRulequoted() {
+					goto l57
+				}
+				goto l56
+			l57:
+				p.position, p.tokenIndex, p.depth = position56, tokenIndex56, depth56
+				if !p.XRulegeneric_name() {
+					goto l24
+				}
 			}
+		l56:
 			p.depth--
 			p.add(RulePegText, position26)
 		}
@@ -1154,7 +1159,64 @@ l41:
 	return false
 }
 
-/* 12 Action0 <- <{ p.OpEnd() }> */
+/* 11 quoted <- <('"' (('\\' .) / (!'"' .))* '"')> */
+func (p *Parser) XRulequoted() bool {
+	position49, tokenIndex49, depth49 := p.position, p.tokenIndex, p.depth
+	{
+		position50 := p.position
+		p.depth++
+		if p.buffer[p.position] != rune('"') {
+			goto l49
+		}
+		p.position++
+	l51:
+		{
+			position52, tokenIndex52, depth52 := p.position, p.tokenIndex, p.depth
+			{
+				position53, tokenIndex53, depth53 := p.position, p.tokenIndex, p.depth
+				if p.buffer[p.position] != rune('\\') {
+					goto l54
+				}
+				p.position++
+				if !p.matchDot() {
+					goto l54
+				}
+				goto l53
+			l54:
+				p.position, p.tokenIndex, p.depth = position53, tokenIndex53, depth53
+				{
+					position55, tokenIndex55, depth55 := p.position, p.tokenIndex, p.depth
+					if p.buffer[p.position] != rune('"') {
+						goto l55
+					}
+					p.position++
+					goto l52
+				l55:
+					p.position, p.tokenIndex, p.depth = position55, tokenIndex55, depth55
+				}
+				if !p.matchDot() {
+					goto l52
+				}
+			}
+		l53:
+			goto l51
+		l52:
+			p.position, p.tokenIndex, p.depth = position52, tokenIndex52, depth52
+		}
+		if p.buffer[p.position] != rune('"') {
+			goto l49
+		}
+		p.position++
+		p.depth--
+		p.add(Rulequoted, position50)
+	}
+	return true
+l49:
+	p.position, p.tokenIndex, p.depth = position49, tokenIndex49, depth49
+	return false
+}
+
+/* 13 Action0 <- <{ p.OpEnd() }> */
 func (p *Parser) XRuleAction0() bool {
 	{
 		p.add(RuleAction0, p.position)
@@ -1162,7 +1224,7 @@ func (p *Parser) XRuleAction0() bool {
 	return true
 }
 
-/* 14 Action1 <- <{ p.OpStart(buffer[begin:end]) }> */
+/* 15 Action1 <- <{ p.OpStart(string([]rune(buffer)[begin:end])) }> */
 func (p *Parser) XRuleAction1() bool {
 	{
 		p.add(RuleAction1, p.position)
@@ -1170,7 +1232,7 @@ func (p *Parser) XRuleAction1() bool {
 	return true
 }
 
-/* 15 Action2 <- <{ p.OpName(buffer[begin:end]) }> */
+/* 16 Action2 <- <{ p.OpName(string([]rune(buffer)[begin:end])) }> */
 func (p *Parser) XRuleAction2() bool {
 	{
 		p.add(RuleAction2, p.position)
@@ -1178,7 +1240,7 @@ func (p *Parser) XRuleAction2() bool {
 	return true
 }
 
-/* 16 Action3 <- <{ p.OpIntValue(buffer[begin:end]) }> */
+/* 17 Action3 <- <{ p.OpIntValue(string([]rune(buffer)[begin:end])) }> */
 func (p *Parser) XRuleAction3() bool {
 	{
 		p.add(RuleAction3, p.position)
@@ -1186,7 +1248,7 @@ func (p *Parser) XRuleAction3() bool {
 	return true
 }
 
-/* 17 Action4 <- <{ p.OpStrValue(buffer[begin:end]) }> */
+/* 18 Action4 <- <{ p.OpStrValue(string([]rune(buffer)[begin:end])) }> */
 func (p *Parser) XRuleAction4() bool {
 	{
 		p.add(RuleAction4, p.position)
@@ -4,12 +4,13 @@
 package main
 
 import (
-	"bsearch/index"
 	"bsearch/engine"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"log"
+	"syscall"
  	"runtime/pprof"
 	"github.com/art4711/bconf"
 	"github.com/art4711/timers"
@@ -29,12 +30,13 @@ func main() {
 		usage()
 	}
 
-	s := engine.EngineState{ Conf: make(bconf.Bconf) }
+	s := engine.EngineState{ Conf: make(bconf.Bconf), Life: engine.NewLifecycle() }
 	s.Timer = timers.NewMemStats()
 
 	timerConf := s.Timer.Start("loadconf")
 	s.Conf.LoadConfFile(flag.Arg(0))
 	timerConf.Stop()
+	s.Admit = s.AdmissionFromConf()
 
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
@@ -46,25 +48,49 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	dbname := s.Conf.GetString("db_name")
 	timerIndex := s.Timer.Start("indexOpen")
-	in, err := index.Open(dbname)
+	err := s.OpenIndexes()
 	timerIndex.Stop()
 	if err != nil {
 		log.Fatal(os.Stderr, "bindex.Open: %v\n", err)
 	}
-	defer in.Close()
-	s.Index = in
+	defer s.CloseIndexes()
 
-	cchan := make(chan string)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			t := s.Timer.Start("reload")
+			if err := s.Reload(""); err != nil {
+				log.Printf("reload: %v", err)
+			}
+			t.Stop()
+		}
+	}()
+
+	// Buffered so that the first stop is never dropped, see engine.Stop.
+	cchan := make(chan string, 1)
 
 	go s.ControlHTTP(cchan)
 	go s.Listener()
+	if s.Conf.GetString("port", "keepalive") != "" {
+		go s.KeepaliveListener()
+	}
 	go s.ListenHTTP()
+
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		for range term {
+			engine.Stop(cchan)
+		}
+	}()
+
 	for {
 		con := <- cchan
 		if con == "stop" {
 			break;
 		}
 	}
+	s.Shutdown()
 }